@@ -0,0 +1,177 @@
+package phimap
+
+import "unsafe"
+
+// batchSize is the number of keys processed together by batch operations.
+// Hashes of a batch are computed ahead, and the home slots are loaded
+// before probing, the independent memory loads overlap with each other,
+// which hides memory latency for large tables.
+const batchSize = 8
+
+// hashBatch computes the home slots of keys, len(keys) <= batchSize.
+func (t *table[K, T]) hashBatch(keys []K, slots *[batchSize]uint64) {
+	if t.hashFn != nil {
+		for i, k := range keys {
+			slots[i] = t.hashFn(uint64(k)) & t.mask
+		}
+		return
+	}
+	for i, k := range keys {
+		slots[i] = phiMix(uint64(k)) & t.mask
+	}
+}
+
+// GetMany looks up keys in the map, the value of keys[i] is written to
+// out[i], or zero value of T if keys[i] is not found.
+// It returns the number of keys found.
+//
+// It panics if len(out) < len(keys).
+func (m *PhiMap[T]) GetMany(keys []uint64, out []T) (found int) {
+	if len(out) < len(keys) {
+		panic("phimap: GetMany with out shorter than keys")
+	}
+	var zero T
+	var slots, first [batchSize]uint64
+	out = out[:len(keys)]
+
+	// Stores to out may alias the map, load the fields once.
+	t := m.tab()
+	dptr, mask := t.dptr, t.mask
+	getK := func(ptr uint64) uint64 {
+		return *(*uint64)(unsafe.Pointer(uintptr(dptr) + uintptr(ptr)*unsafe.Sizeof(entry[uint64, T]{})))
+	}
+	for len(keys) >= batchSize {
+		batch := (*[batchSize]uint64)(keys)
+		t.hashBatch(batch[:], &slots)
+		for i := range batch {
+			first[i] = getK(slots[i])
+		}
+		res := (*[batchSize]T)(out)
+		for i, key := range batch {
+			if key == FREE_KEY {
+				res[i] = t.zeroVal
+				if t.hasZero {
+					found++
+				}
+				continue
+			}
+			ptr, k := slots[i], first[i]
+			for k != key && k != FREE_KEY {
+				ptr = (ptr + 1) & mask
+				k = getK(ptr)
+			}
+			if k == key {
+				res[i] = *(*T)(unsafe.Pointer(uintptr(dptr) + uintptr(ptr)*unsafe.Sizeof(entry[uint64, T]{}) + u64Size))
+				found++
+			} else {
+				res[i] = zero
+			}
+		}
+		keys, out = keys[batchSize:], out[batchSize:]
+	}
+	for i, key := range keys {
+		var ok bool
+		if out[i], ok = t.lookup(key); ok {
+			found++
+		}
+	}
+	return found
+}
+
+// SetMany adds or updates keys with vals to the map, keys[i] is set to
+// vals[i]. It reserves capacity for all keys once before inserting.
+//
+// It panics if len(vals) != len(keys).
+func (m *PhiMap[T]) SetMany(keys []uint64, vals []T) {
+	if len(vals) != len(keys) {
+		panic("phimap: SetMany with mismatched keys and values")
+	}
+	t := m.tab()
+	t.reserve(len(keys))
+
+	// The map won't be rehashed, slots computed ahead remain valid.
+	var slots, first [batchSize]uint64
+	for len(keys) > 0 {
+		n := min(len(keys), batchSize)
+		batch := keys[:n]
+		t.hashBatch(batch, &slots)
+		for i := range batch {
+			first[i] = *t.getK(slots[i])
+		}
+		for i, key := range batch {
+			if key == FREE_KEY {
+				t.setZero(vals[i], true)
+				continue
+			}
+			// Inserting never moves entries, but the home slot may be
+			// taken by a previous key in the batch, load it again.
+			ptr, k := slots[i], first[i]
+			if k != key {
+				k = *t.getK(ptr)
+			}
+			for k != key && k != FREE_KEY {
+				ptr = (ptr + 1) & t.mask
+				k = *t.getK(ptr)
+			}
+			if k == FREE_KEY {
+				*t.getK(ptr) = key
+				t.size++
+			}
+			*t.getV(ptr) = vals[i]
+		}
+		keys, vals = keys[n:], vals[n:]
+	}
+}
+
+// DeleteMany deletes keys from the map, it returns the number of keys
+// deleted. The map shrinks at most once if auto shrinking is enabled.
+func (m *PhiMap[T]) DeleteMany(keys []uint64) (deleted int) {
+	t := m.tab()
+	// Deleting shifts entries backward, but never changes the home slots,
+	// the map is shrunk after all keys are deleted.
+	var slots, first [batchSize]uint64
+	for len(keys) > 0 {
+		n := min(len(keys), batchSize)
+		batch := keys[:n]
+		t.hashBatch(batch, &slots)
+		for i := range batch {
+			first[i] = *t.getK(slots[i])
+		}
+		for i, key := range batch {
+			if key == FREE_KEY {
+				if t.hasZero {
+					var zero T
+					t.hasZero = false
+					t.zeroVal = zero
+					t.size--
+					deleted++
+				}
+				continue
+			}
+			// Deleting never fills a free slot, the key is not found
+			// if its home slot was free.
+			if first[i] == FREE_KEY {
+				continue
+			}
+			ptr := slots[i]
+			for {
+				k := *t.getK(ptr)
+				if k == key {
+					t.shiftKeys(ptr)
+					t.size--
+					deleted++
+					break
+				}
+				if k == FREE_KEY {
+					break
+				}
+				ptr = (ptr + 1) & t.mask
+			}
+		}
+		keys = keys[n:]
+	}
+	if t.size < t.shrinkThreshold {
+		t.shrink()
+	}
+	return deleted
+}
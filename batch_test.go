@@ -0,0 +1,75 @@
+package phimap
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestPhiMap_Batch(t *testing.T) {
+	t.Run("PhiMap", func(t *testing.T) {
+		testPhiMapBatch(t, NewPhiMap[int](WithAutoShrink(0.1)))
+	})
+	t.Run("HashMap", func(t *testing.T) {
+		testPhiMapBatch(t, &NewHashMap[int](WithStrongHash(), WithAutoShrink(0.1)).m)
+	})
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic for GetMany with out shorter than keys")
+		}
+	}()
+	NewPhiMap[int]().GetMany([]uint64{1, 2, 3}, make([]int, 2, 3))
+}
+
+func testPhiMapBatch(t *testing.T, m *PhiMap[int]) {
+	ref := make(map[uint64]int)
+	rnd := rand.New(rand.NewSource(1))
+	for round := 0; round < 300; round++ {
+		// a batch may have duplicate keys
+		n := rnd.Intn(100)
+		keys := make([]uint64, n)
+		vals := make([]int, n)
+		for i := range keys {
+			keys[i] = clusteredKey[uint64](rnd, 5000)
+			vals[i] = rnd.Int()
+		}
+		switch rnd.Intn(3) {
+		case 0, 1:
+			m.SetMany(keys, vals)
+			for i, k := range keys {
+				ref[k] = vals[i]
+			}
+		case 2:
+			want := 0
+			for _, k := range keys {
+				if _, ok := ref[k]; ok {
+					delete(ref, k)
+					want++
+				}
+			}
+			assertEqual(t, want, m.DeleteMany(keys))
+		}
+		assertPhiMapInvariants(t, m)
+		assertEqual(t, len(ref), m.Size())
+
+		out := make([]int, n+1)
+		want := 0
+		for _, k := range keys {
+			if _, ok := ref[k]; ok {
+				want++
+			}
+		}
+		assertEqual(t, want, m.GetMany(keys, out))
+		for i, k := range keys {
+			assertEqual(t, ref[k], out[i])
+		}
+	}
+
+	assertMapEqual(t, slowPhiMap[int]{m}, ref, 5000)
+
+	// Deleting all entries shrinks the map.
+	keys := m.Keys()
+	assertEqual(t, len(keys), m.DeleteMany(keys))
+	assertEqual(t, 0, m.Size())
+	assertEqual(t, arraySize(initSize, fillFactor), len(m.data))
+}
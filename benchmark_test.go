@@ -1,6 +1,8 @@
 package phimap
 
 import (
+	"math/rand"
+	"strconv"
 	"sync"
 	"testing"
 	"unsafe"
@@ -82,6 +84,235 @@ func Benchmark_Concurrent_TypeMap_Get(b *testing.B) {
 	})
 }
 
+func Benchmark_Concurrent_TypeMap_Lookup(b *testing.B) {
+	m := NewTypeMap[uintptr]()
+	typPtrs := fillMap(func(k, v uintptr) {
+		_, _ = m.SetByUintptr(k, func() (uintptr, error) { return v, nil })
+	})
+	m.calibrate(true)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			for _, ptr := range typPtrs {
+				m.LookupByUintptr(ptr)
+			}
+		}
+	})
+}
+
+func Benchmark_PhiMap_Get(b *testing.B) {
+	m := NewPhiMap[uintptr]()
+	typPtrs := fillMap(func(k, v uintptr) { m.Set(uint64(k), v) })
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, ptr := range typPtrs {
+			m.Get(uint64(ptr))
+		}
+	}
+}
+
+func Benchmark_PhiMap_Lookup(b *testing.B) {
+	m := NewPhiMap[uintptr]()
+	typPtrs := fillMap(func(k, v uintptr) { m.Set(uint64(k), v) })
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, ptr := range typPtrs {
+			m.Lookup(uint64(ptr))
+		}
+	}
+}
+
+func Benchmark_PhiMap_Set_Scalar(b *testing.B) {
+	const n = 1000
+	m := NewPhiMap[uint64]()
+	for i := 0; i < n; i++ {
+		m.Set(uint64(i), uint64(i))
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		k := uint64(i % n)
+		m.Set(k, uint64(i)+1000)
+	}
+}
+
+func Benchmark_PhiMap_Set_Struct(b *testing.B) {
+	const n = 1000
+	m := NewPhiMap[AStruct]()
+	for i := 0; i < n; i++ {
+		m.Set(uint64(i), AStruct{A: int64(i)})
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		k := uint64(i % n)
+		m.Set(k, AStruct{A: int64(i), E: i})
+	}
+}
+
+func Benchmark_PhiMap_BulkInsert(b *testing.B) {
+	const n = 1 << 20
+	b.Run("NoHint", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			m := NewPhiMap[uint64]()
+			for k := uint64(1); k <= n; k++ {
+				m.Set(k, k)
+			}
+		}
+	})
+	b.Run("WithCapacity", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			m := NewPhiMapWithCapacity[uint64](n)
+			for k := uint64(1); k <= n; k++ {
+				m.Set(k, k)
+			}
+		}
+	})
+	b.Run("Reserve", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			m := NewPhiMap[uint64]()
+			m.Reserve(n)
+			for k := uint64(1); k <= n; k++ {
+				m.Set(k, k)
+			}
+		}
+	})
+}
+
+func Benchmark_HashMap_Get(b *testing.B) {
+	for _, bc := range []struct {
+		name string
+		opts []Option
+	}{
+		{"Seeded", nil},
+		{"StrongHash", []Option{WithStrongHash()}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			m := NewHashMap[uintptr](bc.opts...)
+			typPtrs := fillMap(func(k, v uintptr) { m.Set(uint64(k), v) })
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, ptr := range typPtrs {
+					m.Get(uint64(ptr))
+				}
+			}
+		})
+	}
+}
+
+func Benchmark_Hasher_Get(b *testing.B) {
+	const n = 10000
+	clustered := make([]uint64, n)
+	random := make([]uint64, n)
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < n; i++ {
+		clustered[i] = 0xc000000000 + uint64(i)*64 // like aligned pointers
+		random[i] = rnd.Uint64()
+	}
+	hashers := []struct {
+		name   string
+		hasher Hasher
+	}{
+		{"Identity", IdentityHasher},
+		{"Fibonacci", FibonacciHasher},
+		{"Murmur3", Murmur3Hasher},
+		{"WyHash", WyHasher},
+	}
+	for _, keySet := range []struct {
+		name string
+		keys []uint64
+	}{
+		{"Clustered", clustered},
+		{"Random", random},
+	} {
+		keys := keySet.keys
+		b.Run(keySet.name+"/PhiMap", func(b *testing.B) {
+			m := NewPhiMapWithCapacity[uint64](n)
+			for _, k := range keys {
+				m.Set(k, k)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				m.Get(keys[i%n])
+			}
+		})
+		for _, h := range hashers {
+			b.Run(keySet.name+"/"+h.name, func(b *testing.B) {
+				m := NewHashMap[uint64](WithHasher(h.hasher), WithInitialCapacity(n))
+				for _, k := range keys {
+					m.Set(k, k)
+				}
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					m.Get(keys[i%n])
+				}
+			})
+		}
+	}
+}
+
+func Benchmark_RobinHoodMap(b *testing.B) {
+	const n = 100000
+	keys := make([]uint64, n)
+	misses := make([]uint64, n)
+	for i := 0; i < n; i++ {
+		keys[i] = 0xc000000000 + uint64(i)*64 // like aligned pointers
+		misses[i] = keys[i] + 8
+	}
+	for _, f := range []float64{0.6, 0.9} {
+		name := strconv.FormatFloat(f, 'f', -1, 64)
+		pm := NewPhiMap[uint64](WithFillFactor(f))
+		rm := NewRobinHoodMap[uint64](WithFillFactor(f))
+		for _, k := range keys {
+			pm.Set(k, k)
+			rm.Set(k, k)
+		}
+		b.Run("Hit/PhiMap/"+name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				pm.Get(keys[i%n])
+			}
+		})
+		b.Run("Hit/RobinHood/"+name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				rm.Get(keys[i%n])
+			}
+		})
+		b.Run("Miss/PhiMap/"+name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				pm.Get(misses[i%n])
+			}
+		})
+		b.Run("Miss/RobinHood/"+name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				rm.Get(misses[i%n])
+			}
+		})
+		b.Run("SetDelete/PhiMap/"+name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				k := keys[i%n]
+				pm.Delete(k)
+				pm.Set(k, k)
+			}
+		})
+		b.Run("SetDelete/RobinHood/"+name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				k := keys[i%n]
+				rm.Delete(k)
+				rm.Set(k, k)
+			}
+		})
+	}
+}
+
 func fillMap(setfunc func(k, v uintptr)) []uintptr {
 	var values = []any{
 		TestType1{},
@@ -105,3 +336,240 @@ func fillMap(setfunc func(k, v uintptr)) []uintptr {
 	}
 	return ptrs
 }
+
+func Benchmark_SwissMap(b *testing.B) {
+	const n = 1000000
+	keys := make([]uint64, n)
+	misses := make([]uint64, n)
+	for i := 0; i < n; i++ {
+		keys[i] = 0xc000000000 + uint64(i)*64 // like aligned pointers
+		misses[i] = keys[i] + 8
+	}
+	pm := NewPhiMap[uint64]()
+	sm := NewSwissMap[uint64](0)
+	for _, k := range keys {
+		pm.Set(k, k)
+		sm.Set(k, k)
+	}
+	rnd := rand.New(rand.NewSource(1))
+	rnd.Shuffle(n, func(i, j int) {
+		keys[i], keys[j] = keys[j], keys[i]
+		misses[i], misses[j] = misses[j], misses[i]
+	})
+	b.Run("Hit/PhiMap", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			pm.Get(keys[i%n])
+		}
+	})
+	b.Run("Hit/SwissMap", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			sm.Get(keys[i%n])
+		}
+	})
+	b.Run("Miss/PhiMap", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			pm.Get(misses[i%n])
+		}
+	})
+	b.Run("Miss/SwissMap", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			sm.Get(misses[i%n])
+		}
+	})
+	b.Run("Set/PhiMap", func(b *testing.B) {
+		m := NewPhiMap[uint64]()
+		for i := 0; i < b.N; i++ {
+			m.Set(keys[i%n], uint64(i))
+		}
+	})
+	b.Run("Set/SwissMap", func(b *testing.B) {
+		m := NewSwissMap[uint64](0)
+		for i := 0; i < b.N; i++ {
+			m.Set(keys[i%n], uint64(i))
+		}
+	})
+}
+
+var splitMapSink uint64
+
+func Benchmark_SplitMap(b *testing.B) {
+	const n = 1000000
+	keys := make([]uint64, n)
+	misses := make([]uint64, n)
+	for i := 0; i < n; i++ {
+		keys[i] = 0xc000000000 + uint64(i)*64 // like aligned pointers
+		misses[i] = keys[i] + 8
+	}
+	rnd := rand.New(rand.NewSource(1))
+	rnd.Shuffle(n, func(i, j int) {
+		keys[i], keys[j] = keys[j], keys[i]
+		misses[i], misses[j] = misses[j], misses[i]
+	})
+
+	type Value [4]uint64
+	pm := NewPhiMap[Value]()
+	sm := NewSplitMap[Value]()
+	for _, k := range keys {
+		pm.Set(k, Value{k})
+		sm.Set(k, Value{k})
+	}
+	b.Run("Hit/PhiMap", func(b *testing.B) {
+		var sum uint64
+		for i := 0; i < b.N; i++ {
+			sum += pm.Get(keys[i%n])[0]
+		}
+		splitMapSink = sum
+	})
+	b.Run("Hit/SplitMap", func(b *testing.B) {
+		var sum uint64
+		for i := 0; i < b.N; i++ {
+			sum += sm.Get(keys[i%n])[0]
+		}
+		splitMapSink = sum
+	})
+	b.Run("Miss/PhiMap", func(b *testing.B) {
+		var sum uint64
+		for i := 0; i < b.N; i++ {
+			sum += pm.Get(misses[i%n])[0]
+		}
+		splitMapSink = sum
+	})
+	b.Run("Miss/SplitMap", func(b *testing.B) {
+		var sum uint64
+		for i := 0; i < b.N; i++ {
+			sum += sm.Get(misses[i%n])[0]
+		}
+		splitMapSink = sum
+	})
+	b.Run("Has/PhiMap", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			pm.Has(keys[i%n])
+		}
+	})
+	b.Run("Has/SplitMap", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			sm.Has(keys[i%n])
+		}
+	})
+}
+
+func Benchmark_PhiMap_Update(b *testing.B) {
+	const n = 10000
+	m := NewPhiMap[int]()
+	for i := 0; i < n; i++ {
+		m.Set(uint64(i)*64, i)
+	}
+	b.Run("GetSet", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			k := uint64(i%n) * 64
+			m.Set(k, m.Get(k)+1)
+		}
+	})
+	b.Run("Update", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			m.Update(uint64(i%n)*64, func(old int, _ bool) (int, bool) {
+				return old + 1, true
+			})
+		}
+	})
+	b.Run("Swap", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			m.Swap(uint64(i%n)*64, i)
+		}
+	})
+}
+
+func Benchmark_PhiMap_Batch(b *testing.B) {
+	const n = 1000000
+	const batch = 1000
+	keys := make([]uint64, n)
+	vals := make([]uint64, n)
+	for i := range keys {
+		keys[i] = 0xc000000000 + uint64(i)*64 // like aligned pointers
+		vals[i] = uint64(i)
+	}
+	rnd := rand.New(rand.NewSource(1))
+	rnd.Shuffle(n, func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+	m := NewPhiMap[uint64]()
+	m.SetMany(keys, vals)
+	out := make([]uint64, batch)
+
+	b.Run("Get", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			off := (i * batch) % n
+			for j, k := range keys[off : off+batch] {
+				out[j] = m.Get(k)
+			}
+		}
+	})
+	b.Run("GetMany", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			off := (i * batch) % n
+			m.GetMany(keys[off:off+batch], out)
+		}
+	})
+	b.Run("Set", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			off := (i * batch) % n
+			for j, k := range keys[off : off+batch] {
+				m.Set(k, vals[off+j])
+			}
+		}
+	})
+	b.Run("SetMany", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			off := (i * batch) % n
+			m.SetMany(keys[off:off+batch], vals[off:off+batch])
+		}
+	})
+	b.Run("Delete", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			off := (i * batch) % n
+			for _, k := range keys[off : off+batch] {
+				m.Delete(k)
+			}
+			b.StopTimer()
+			m.SetMany(keys[off:off+batch], vals[off:off+batch])
+			b.StartTimer()
+		}
+	})
+	b.Run("DeleteMany", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			off := (i * batch) % n
+			m.DeleteMany(keys[off : off+batch])
+			b.StopTimer()
+			m.SetMany(keys[off:off+batch], vals[off:off+batch])
+			b.StartTimer()
+		}
+	})
+}
+
+func Benchmark_StaticMap(b *testing.B) {
+	for _, n := range []int{1000, 1000000} {
+		keys := make([]uint64, n)
+		rnd := rand.New(rand.NewSource(1))
+		for i := range keys {
+			keys[i] = rnd.Uint64()
+		}
+		pm := NewPhiMap[int](WithFillFactor(0.6))
+		for i, k := range keys {
+			pm.Set(k, i)
+		}
+		sm := BuildStatic(pm.All())
+		b.Run(strconv.Itoa(n)+"/PhiMap", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_ = pm.Get(keys[i%n])
+			}
+		})
+		b.Run(strconv.Itoa(n)+"/StaticMap", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_ = sm.Get(keys[i%n])
+			}
+		})
+		b.Run(strconv.Itoa(n)+"/Build", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				BuildStatic(pm.All())
+			}
+		})
+	}
+}
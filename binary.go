@@ -0,0 +1,235 @@
+package phimap
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"reflect"
+	"unsafe"
+)
+
+// Binary format of a PhiMap, all integers are little-endian:
+//
+//	magic         [4]byte  "PHIM"
+//	version       uint8    binaryVersion
+//	reserved      [3]byte
+//	fill factor   float64
+//	shrink factor float64
+//	count         uint64   number of entries
+//	entries       count * (key uint64, value length uvarint, value)
+//	checksum      uint32   CRC-32C of all the preceding bytes
+const (
+	binaryMagic      = "PHIM"
+	binaryVersion    = 1
+	binaryHeaderSize = 32
+
+	// an entry takes at least 9 bytes, a key and the length of value
+	minEntrySize = 9
+)
+
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+var (
+	_ encoding.BinaryMarshaler   = (*PhiMap[int])(nil)
+	_ encoding.BinaryUnmarshaler = (*PhiMap[int])(nil)
+)
+
+// ValueCodec encodes and decodes values of a PhiMap for binary
+// serialization.
+//
+// Append appends the encoded value to b and returns the extended buffer,
+// Decode decodes a value from data, which is exactly the bytes appended
+// by Append.
+type ValueCodec[T any] struct {
+	Append func(b []byte, v T) ([]byte, error)
+	Decode func(data []byte) (T, error)
+}
+
+// defaultCodec returns a ValueCodec for T if T implements
+// encoding.BinaryMarshaler and *T implements encoding.BinaryUnmarshaler,
+// or T is an integer, or a fixed-size type supported by encoding/binary.
+func defaultCodec[T any]() (ValueCodec[T], error) {
+	var zero T
+	_, isMarshaler := any(zero).(encoding.BinaryMarshaler)
+	_, isUnmarshaler := any(&zero).(encoding.BinaryUnmarshaler)
+	if isMarshaler && isUnmarshaler {
+		return ValueCodec[T]{
+			Append: func(b []byte, v T) ([]byte, error) {
+				data, err := any(v).(encoding.BinaryMarshaler).MarshalBinary()
+				return append(b, data...), err
+			},
+			Decode: func(data []byte) (v T, err error) {
+				err = any(&v).(encoding.BinaryUnmarshaler).UnmarshalBinary(data)
+				return v, err
+			},
+		}, nil
+	}
+	// int, uint and uintptr are not fixed-size, encode them as 64 bits.
+	switch reflect.TypeFor[T]().Kind() {
+	case reflect.Int:
+		return intCodec[T, int](), nil
+	case reflect.Uint:
+		return intCodec[T, uint](), nil
+	case reflect.Uintptr:
+		return intCodec[T, uintptr](), nil
+	}
+	if binary.Size(zero) >= 0 {
+		return ValueCodec[T]{
+			Append: func(b []byte, v T) ([]byte, error) {
+				return binary.Append(b, binary.LittleEndian, v)
+			},
+			Decode: func(data []byte) (v T, err error) {
+				_, err = binary.Decode(data, binary.LittleEndian, &v)
+				return v, err
+			},
+		}, nil
+	}
+	return ValueCodec[T]{}, fmt.Errorf("phimap: value type %T is not binary-marshalable, use a ValueCodec", zero)
+}
+
+// intCodec returns a ValueCodec for T whose underlying type is I,
+// which encodes values as 64 bits.
+// Decode fails if a value doesn't fit in I, e.g. a map written on
+// a 64-bit host is loaded on a 32-bit one.
+func intCodec[T any, I int | uint | uintptr]() ValueCodec[T] {
+	return ValueCodec[T]{
+		Append: func(b []byte, v T) ([]byte, error) {
+			return binary.LittleEndian.AppendUint64(b, uint64(*(*I)(unsafe.Pointer(&v)))), nil
+		},
+		Decode: func(data []byte) (v T, err error) {
+			if len(data) != 8 {
+				return v, errors.New("invalid integer")
+			}
+			x := binary.LittleEndian.Uint64(data)
+			// I(x) is sign-extended back if I is signed.
+			if uint64(I(x)) != x {
+				return v, fmt.Errorf("integer %#x overflows %T", x, v)
+			}
+			*(*I)(unsafe.Pointer(&v)) = I(x)
+			return v, nil
+		},
+	}
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+//
+// T must implement encoding.BinaryMarshaler and *T must implement
+// encoding.BinaryUnmarshaler, or T is an integer, or a fixed-size type
+// supported by encoding/binary, e.g. floats, and structs of fixed-size
+// integers and floats, else use MarshalBinaryWith.
+func (m *PhiMap[T]) MarshalBinary() ([]byte, error) {
+	codec, err := defaultCodec[T]()
+	if err != nil {
+		return nil, err
+	}
+	return m.MarshalBinaryWith(codec)
+}
+
+// MarshalBinaryWith is same as MarshalBinary, but it encodes values
+// with codec.
+func (m *PhiMap[T]) MarshalBinaryWith(codec ValueCodec[T]) ([]byte, error) {
+	b := make([]byte, 0, binaryHeaderSize+m.size*(minEntrySize+1)+4)
+	b = append(b, binaryMagic...)
+	b = append(b, binaryVersion, 0, 0, 0)
+	b = binary.LittleEndian.AppendUint64(b, math.Float64bits(m.fillFactor))
+	b = binary.LittleEndian.AppendUint64(b, math.Float64bits(m.shrinkFactor))
+	b = binary.LittleEndian.AppendUint64(b, uint64(m.size))
+
+	var err error
+	var val []byte
+	appendEntry := func(k uint64, v T) bool {
+		val, err = codec.Append(val[:0], v)
+		if err != nil {
+			return false
+		}
+		b = binary.LittleEndian.AppendUint64(b, k)
+		b = binary.AppendUvarint(b, uint64(len(val)))
+		b = append(b, val...)
+		return true
+	}
+	if m.hasZero && !appendEntry(FREE_KEY, m.zeroVal) {
+		return nil, err
+	}
+	for i := range m.data {
+		e := &m.data[i]
+		if e.K != FREE_KEY && !appendEntry(e.K, e.V) {
+			return nil, err
+		}
+	}
+	b = binary.LittleEndian.AppendUint32(b, crc32.Checksum(b, crc32c))
+	return b, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+// It replaces all entries of the map with the decoded ones,
+// the fill factor and auto shrinking setting are also restored.
+//
+// The hash table is allocated once to hold all entries.
+// See MarshalBinary for the supported value types.
+func (m *PhiMap[T]) UnmarshalBinary(data []byte) error {
+	codec, err := defaultCodec[T]()
+	if err != nil {
+		return err
+	}
+	return m.UnmarshalBinaryWith(data, codec)
+}
+
+// UnmarshalBinaryWith is same as UnmarshalBinary, but it decodes values
+// with codec.
+func (m *PhiMap[T]) UnmarshalBinaryWith(data []byte, codec ValueCodec[T]) error {
+	if len(data) < binaryHeaderSize+4 || string(data[:4]) != binaryMagic {
+		return errors.New("phimap: invalid binary data")
+	}
+	if version := data[4]; version != binaryVersion {
+		return fmt.Errorf("phimap: unsupported binary version %d", version)
+	}
+	body, sum := data[:len(data)-4], binary.LittleEndian.Uint32(data[len(data)-4:])
+	if crc32.Checksum(body, crc32c) != sum {
+		return errors.New("phimap: binary data checksum mismatch")
+	}
+	fill := math.Float64frombits(binary.LittleEndian.Uint64(data[8:]))
+	shrink := math.Float64frombits(binary.LittleEndian.Uint64(data[16:]))
+	count := binary.LittleEndian.Uint64(data[24:])
+	if !(fill >= minFillFactor && fill <= maxFillFactor) ||
+		!(shrink >= 0 && shrink <= fill/4) {
+		return errors.New("phimap: invalid binary data")
+	}
+	body = body[binaryHeaderSize:]
+	if count > uint64(len(body)/minEntrySize) {
+		return errors.New("phimap: invalid binary data")
+	}
+
+	// Decode to a new hash table, the map is not changed on error.
+	tmp := &table[uint64, T]{
+		fillFactor:   fill,
+		shrinkFactor: shrink,
+		hashFn:       m.hashFn,
+	}
+	tmp.alloc(arraySize(int(count), fill))
+	for i := uint64(0); i < count; i++ {
+		if len(body) < minEntrySize {
+			return errors.New("phimap: truncated binary data")
+		}
+		k := binary.LittleEndian.Uint64(body)
+		n, w := binary.Uvarint(body[8:])
+		if w <= 0 || n > uint64(len(body)-8-w) {
+			return errors.New("phimap: truncated binary data")
+		}
+		body = body[8+w:]
+		v, err := codec.Decode(body[:n])
+		if err != nil {
+			return fmt.Errorf("phimap: decode value: %w", err)
+		}
+		body = body[n:]
+		tmp.set(k, v)
+	}
+	if len(body) != 0 {
+		return errors.New("phimap: invalid binary data")
+	}
+
+	m.tab().replace(tmp)
+	return nil
+}
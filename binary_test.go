@@ -0,0 +1,182 @@
+package phimap
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPhiMap_MarshalBinary(t *testing.T) {
+	t.Run("int", func(t *testing.T) {
+		m := NewPhiMap[int](WithFillFactor(0.8), WithAutoShrink(0.1))
+		for i := 0; i < 10000; i++ {
+			m.Set(uint64(i)*64, -i) // including key 0
+		}
+		data, err := m.MarshalBinary()
+		assertEqual(t, nil, err)
+
+		var got PhiMap[int]
+		assertEqual(t, nil, got.UnmarshalBinary(data))
+		assertEqual(t, m.Size(), got.Size())
+		assertEqual(t, 0.8, got.fillFactor)
+		assertEqual(t, 0.1, got.shrinkFactor)
+		assertEqual(t, arraySize(m.Size(), 0.8), len(got.data))
+		assertPhiMapInvariants(t, &got)
+		for k, v := range m.All() {
+			assertEqual(t, v, got.Get(k))
+		}
+	})
+
+	t.Run("named uint", func(t *testing.T) {
+		type id uint
+		m := NewPhiMap[id]()
+		m.Set(1, 1)
+		m.Set(2, ^id(0))
+		data, err := m.MarshalBinary()
+		assertEqual(t, nil, err)
+
+		// A zero HashMap gets a seeded hash function.
+		var got HashMap[id]
+		assertEqual(t, nil, got.UnmarshalBinary(data))
+		assertEqual(t, true, got.m.hashFn != nil)
+		assertEqual(t, 2, got.Size())
+		assertEqual(t, id(1), got.Get(1))
+		assertEqual(t, ^id(0), got.Get(2))
+	})
+
+	t.Run("int overflow", func(t *testing.T) {
+		// int64 and uint64 are encoded in the same way as int and uint.
+		signed := NewPhiMap[int64]()
+		signed.Set(1, -1)
+		signed.Set(2, -1<<40)
+		data, err := signed.MarshalBinary()
+		assertEqual(t, nil, err)
+		var got PhiMap[int]
+		err = got.UnmarshalBinary(data)
+		if strconv.IntSize == 32 {
+			assertEqual(t, true, err != nil && strings.Contains(err.Error(), "overflows"))
+		} else {
+			assertEqual(t, nil, err)
+			assertEqual(t, int64(-1<<40), int64(got.Get(2)))
+		}
+
+		unsigned := NewPhiMap[uint64]()
+		unsigned.Set(1, 1<<32)
+		data, err = unsigned.MarshalBinary()
+		assertEqual(t, nil, err)
+		var gotU PhiMap[uint]
+		err = gotU.UnmarshalBinary(data)
+		assertEqual(t, strconv.IntSize == 32, err != nil)
+
+		// A negative value fits after sign extension.
+		signed.Delete(2)
+		data, err = signed.MarshalBinary()
+		assertEqual(t, nil, err)
+		assertEqual(t, nil, got.UnmarshalBinary(data))
+		assertEqual(t, -1, got.Get(1))
+	})
+
+	t.Run("fixed size struct", func(t *testing.T) {
+		type point struct {
+			X, Y float64
+			Z    int32
+		}
+		m := NewPhiMap[point]()
+		for i := 1; i < 1000; i++ {
+			m.Set(uint64(i), point{float64(i), -float64(i), int32(i)})
+		}
+		data, err := m.MarshalBinary()
+		assertEqual(t, nil, err)
+		got := NewPhiMap[point]()
+		got.Set(100000, point{})
+		assertEqual(t, nil, got.UnmarshalBinary(data))
+		assertEqual(t, m.Size(), got.Size())
+		assertEqual(t, false, got.Has(100000))
+		for k, v := range m.All() {
+			assertEqual(t, v, got.Get(k))
+		}
+	})
+
+	t.Run("binary marshaler", func(t *testing.T) {
+		m := NewHashMap[time.Time]()
+		now := time.Now().Round(0)
+		for i := 0; i < 1000; i++ {
+			m.Set(uint64(i), now.Add(time.Duration(i)*time.Second))
+		}
+		data, err := m.MarshalBinary()
+		assertEqual(t, nil, err)
+		got := NewHashMap[time.Time]()
+		assertEqual(t, nil, got.UnmarshalBinary(data))
+		assertEqual(t, m.Size(), got.Size())
+		for k, v := range m.All() {
+			assertEqual(t, true, v.Equal(got.Get(k)))
+		}
+	})
+
+	t.Run("codec", func(t *testing.T) {
+		codec := ValueCodec[string]{
+			Append: func(b []byte, v string) ([]byte, error) {
+				return append(b, v...), nil
+			},
+			Decode: func(data []byte) (string, error) {
+				return string(data), nil
+			},
+		}
+		m := NewPhiMap[string]()
+		_, err := m.MarshalBinary()
+		assertEqual(t, true, err != nil)
+
+		for i := 0; i < 1000; i++ {
+			m.Set(uint64(i), strings.Repeat("x", i%300))
+		}
+		data, err := m.MarshalBinaryWith(codec)
+		assertEqual(t, nil, err)
+		got := NewPhiMap[string]()
+		assertEqual(t, nil, got.UnmarshalBinaryWith(data, codec))
+		assertEqual(t, m.Size(), got.Size())
+		for k, v := range m.All() {
+			assertEqual(t, v, got.Get(k))
+		}
+		var hm HashMap[string]
+		assertEqual(t, nil, hm.UnmarshalBinaryWith(data, codec))
+		assertEqual(t, true, hm.m.hashFn != nil)
+		assertEqual(t, m.Size(), hm.Size())
+
+		errCodec := codec
+		errCodec.Decode = func([]byte) (string, error) { return "", errors.New("test error") }
+		err = got.UnmarshalBinaryWith(data, errCodec)
+		assertEqual(t, true, err != nil && strings.Contains(err.Error(), "test error"))
+		assertEqual(t, m.Size(), got.Size()) // not changed on error
+	})
+
+	t.Run("invalid data", func(t *testing.T) {
+		m := NewPhiMap[uint32]()
+		for i := 0; i < 100; i++ {
+			m.Set(uint64(i), uint32(i))
+		}
+		data, _ := m.MarshalBinary()
+		var got PhiMap[uint32]
+
+		corrupted := append([]byte(nil), data...)
+		corrupted[40] ^= 1
+		err := got.UnmarshalBinary(corrupted)
+		assertEqual(t, true, err != nil && strings.Contains(err.Error(), "checksum"))
+
+		version := append([]byte(nil), data...)
+		version[4] = 2
+		err = got.UnmarshalBinary(version)
+		assertEqual(t, true, err != nil && strings.Contains(err.Error(), "version"))
+
+		// truncated data with a valid checksum
+		truncated := append([]byte(nil), data[:len(data)-20]...)
+		truncated = binary.LittleEndian.AppendUint32(truncated, crc32.Checksum(truncated, crc32c))
+		assertEqual(t, true, got.UnmarshalBinary(truncated) != nil)
+
+		assertEqual(t, true, got.UnmarshalBinary(data[:10]) != nil)
+		assertEqual(t, 0, got.Size())
+	})
+}
@@ -0,0 +1,75 @@
+//go:build phimapdebug
+
+package phimap
+
+import (
+	"bytes"
+	"fmt"
+	"unsafe"
+)
+
+// maxStalePtrs limits the number of invalidated pointers to check.
+const maxStalePtrs = 1024
+
+// ptrDebug detects writing through pointers returned by GetPtr and
+// SetPtr after the map is rehashed, it is enabled by build tag
+// phimapdebug.
+//
+// gen is the generation counter, which is increased when the map is
+// rehashed, or entries are moved or cleared in place, which invalidates
+// all pointers handed out.
+// The old hash table is not changed by the map after rehashing,
+// thus ptrDebug saves the values of pointers into it, and checks
+// whether they are changed.
+// A pointer invalidated in place still points into the hash table,
+// which is changed by the map, writing through it can't be told from
+// the map's own writes. It is kept tracked, and is checked once the
+// hash table is left by rehashing.
+type ptrDebug[T any] struct {
+	gen   uint64
+	ptrs  map[*T]uint64 // generation when a pointer is handed out
+	stale []stalePtr[T]
+}
+
+type stalePtr[T any] struct {
+	p    *T
+	gen  uint64
+	snap []byte
+}
+
+func valueBytes[T any](p *T) []byte {
+	return unsafe.Slice((*byte)(unsafe.Pointer(p)), unsafe.Sizeof(*p))
+}
+
+func (d *ptrDebug[T]) track(p *T) {
+	if d.ptrs == nil {
+		d.ptrs = make(map[*T]uint64)
+	}
+	d.ptrs[p] = d.gen
+}
+
+// invalidate is called when entries are moved or cleared in place.
+func (d *ptrDebug[T]) invalidate() {
+	d.gen++
+}
+
+func (d *ptrDebug[T]) rehash() {
+	d.check()
+	for p, gen := range d.ptrs {
+		snap := bytes.Clone(valueBytes(p))
+		d.stale = append(d.stale, stalePtr[T]{p: p, gen: gen, snap: snap})
+	}
+	if n := len(d.stale); n > maxStalePtrs {
+		d.stale = append(d.stale[:0], d.stale[n-maxStalePtrs:]...)
+	}
+	clear(d.ptrs)
+	d.gen++
+}
+
+func (d *ptrDebug[T]) check() {
+	for _, s := range d.stale {
+		if !bytes.Equal(s.snap, valueBytes(s.p)) {
+			panic(fmt.Sprintf("phimap: pointer returned by GetPtr or SetPtr at generation %d is written after rehashing, current generation %d", s.gen, d.gen))
+		}
+	}
+}
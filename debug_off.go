@@ -0,0 +1,16 @@
+//go:build !phimapdebug
+
+package phimap
+
+// ptrDebug detects writing through pointers returned by GetPtr and
+// SetPtr after the map is rehashed, it is enabled by build tag
+// phimapdebug. This is the no-op version which takes no space.
+type ptrDebug[T any] struct{}
+
+func (d *ptrDebug[T]) track(p *T) {}
+
+func (d *ptrDebug[T]) invalidate() {}
+
+func (d *ptrDebug[T]) rehash() {}
+
+func (d *ptrDebug[T]) check() {}
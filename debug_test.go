@@ -0,0 +1,62 @@
+//go:build phimapdebug
+
+package phimap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPhiMap_GetPtr_Debug(t *testing.T) {
+	m := NewPhiMap[int]()
+	p, _ := m.SetPtr(1)
+	*p = 1
+	for i := 2; i < 1000; i++ {
+		m.Set(uint64(i), i)
+	}
+
+	// Writing through the pointer after rehashing is lost.
+	*p = 100
+	assertEqual(t, 1, m.Get(1))
+
+	defer func() {
+		r := recover()
+		msg, _ := r.(string)
+		if !strings.Contains(msg, "written after rehashing") {
+			t.Errorf("expected panic of using invalid pointer, got %v", r)
+		}
+	}()
+	m.GetPtr(1)
+}
+
+func TestPhiMap_GetPtr_DebugInvalidate(t *testing.T) {
+	m := NewPhiMap[int]()
+	p, _ := m.SetPtr(1)
+	for i := 2; i < 10; i++ {
+		m.Set(uint64(i), i)
+	}
+
+	// Deleting and clearing invalidate pointers in place.
+	gen := m.debug.gen
+	m.Delete(2)
+	assertEqual(t, gen+1, m.debug.gen)
+	m.DeleteFunc(func(k uint64, _ int) bool { return k == 3 })
+	assertEqual(t, gen+2, m.debug.gen)
+	m.Clear()
+	assertEqual(t, gen+3, m.debug.gen)
+
+	// The pointer is still checked once the hash table is left.
+	for i := 1; i < 1000; i++ {
+		m.Set(uint64(i), i)
+	}
+	*p = 100
+
+	defer func() {
+		r := recover()
+		msg, _ := r.(string)
+		if !strings.Contains(msg, "at generation 0 is written after rehashing") {
+			t.Errorf("expected panic of using invalid pointer, got %v", r)
+		}
+	}()
+	m.GetPtr(1)
+}
@@ -0,0 +1,206 @@
+package phimap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"iter"
+	"unsafe"
+)
+
+// File format of a FrozenIntMap, all integers are little-endian uint64
+// unless noted otherwise:
+//
+//	magic     [4]byte  "PHIF"
+//	version   uint8    frozenVersion
+//	reserved  [3]byte
+//	phi       multiplier of phiMix, INT_PHI
+//	shift     right shift of phiMix, 16
+//	mask      number of slots - 1
+//	count     number of entries, including key 0
+//	hasZero   1 if key 0 exists, else 0
+//	zeroVal   value of key 0
+//	checksum  CRC-32C of all the preceding bytes of the header
+//	table     (mask+1) * (key, value), in the same layout as PhiMap's
+//
+// The table is at an 8 bytes aligned offset, it is used in place
+// without decoding, thus it is not covered by the checksum.
+const (
+	frozenMagic      = "PHIF"
+	frozenVersion    = 2
+	frozenHeaderSize = 64
+	frozenPhiShift   = 16
+)
+
+// WriteFrozenIntMap writes the hash table of m to w, which can be
+// loaded by LoadFrozenIntMap or OpenFrozenIntMap without decoding.
+func WriteFrozenIntMap(w io.Writer, m *IntIntMap) error {
+	pm := &m.m
+	var hasZero uint64
+	if pm.hasZero {
+		hasZero = 1
+	}
+	header := make([]byte, 0, frozenHeaderSize)
+	header = append(header, frozenMagic...)
+	header = append(header, frozenVersion, 0, 0, 0)
+	for _, x := range []uint64{
+		INT_PHI, frozenPhiShift, pm.mask, uint64(pm.size), hasZero, pm.zeroVal,
+	} {
+		header = binary.LittleEndian.AppendUint64(header, x)
+	}
+	header = binary.LittleEndian.AppendUint64(header, uint64(crc32.Checksum(header, crc32c)))
+
+	bw := bufio.NewWriterSize(w, 64<<10)
+	bw.Write(header)
+	var buf [16]byte
+	for _, e := range pm.data {
+		binary.LittleEndian.PutUint64(buf[:], e.K)
+		binary.LittleEndian.PutUint64(buf[8:], e.V)
+		bw.Write(buf[:])
+	}
+	return bw.Flush()
+}
+
+// FrozenIntMap is a read-only map from uint64 to uint64, whose hash
+// table is used in place from a byte slice or a memory mapped file,
+// a process can call Get immediately without decoding the table,
+// which is suitable for huge lookup tables built offline.
+//
+// It uses the same hashing and linear probing as PhiMap.
+// A FrozenIntMap is safe to use concurrently.
+type FrozenIntMap struct {
+	dptr unsafe.Pointer
+	mask uint64
+	size int
+
+	hasZero bool
+	zeroVal uint64
+
+	data  []byte
+	close func() error
+}
+
+// LoadFrozenIntMap returns a FrozenIntMap which uses data written by
+// WriteFrozenIntMap in place, data must be 8 bytes aligned and must not
+// be modified while the map is in use.
+// The header is verified, but the table is not, which would take
+// a full scan.
+//
+// It only supports little-endian CPUs.
+func LoadFrozenIntMap(data []byte) (*FrozenIntMap, error) {
+	if binary.NativeEndian.Uint16([]byte{1, 0}) != 1 {
+		return nil, errors.New("phimap: FrozenIntMap only supports little-endian CPUs")
+	}
+	if len(data) < frozenHeaderSize || string(data[:4]) != frozenMagic {
+		return nil, errors.New("phimap: invalid frozen map data")
+	}
+	if version := data[4]; version != frozenVersion {
+		return nil, fmt.Errorf("phimap: unsupported frozen map version %d", version)
+	}
+	u64 := func(i int) uint64 { return binary.LittleEndian.Uint64(data[8+i*8:]) }
+	if u64(6) != uint64(crc32.Checksum(data[:frozenHeaderSize-8], crc32c)) {
+		return nil, errors.New("phimap: frozen map header checksum mismatch")
+	}
+	if u64(0) != INT_PHI || u64(1) != frozenPhiShift {
+		return nil, errors.New("phimap: unsupported frozen map hash parameters")
+	}
+
+	// Entries in the table never exceed the threshold of the max
+	// fill factor.
+	mask, count, hasZero := u64(2), u64(3), u64(4)
+	slots := mask + 1
+	if slots == 0 || slots&mask != 0 || slots > uint64(len(data)) ||
+		uint64(len(data)-frozenHeaderSize) != slots*16 ||
+		hasZero > 1 || count < hasZero ||
+		count-hasZero > uint64(calcThreshold(int(slots), maxFillFactor)) {
+		return nil, errors.New("phimap: invalid frozen map data")
+	}
+	table := data[frozenHeaderSize:]
+	if uintptr(unsafe.Pointer(&table[0]))%8 != 0 {
+		return nil, errors.New("phimap: frozen map data is not 8 bytes aligned")
+	}
+	return &FrozenIntMap{
+		dptr:    unsafe.Pointer(&table[0]),
+		mask:    mask,
+		size:    int(count),
+		hasZero: hasZero != 0,
+		zeroVal: u64(5),
+		data:    data,
+	}, nil
+}
+
+// Len returns the number of entries in the map.
+func (m *FrozenIntMap) Len() int {
+	return m.size
+}
+
+// Get returns the value and true if the key is found,
+// else it returns 0 and false.
+// It is optimized to be inline-able.
+//
+// The table is not verified by LoadFrozenIntMap, probing stops after
+// visiting all slots, thus a corrupted table without free slot
+// won't make it loop forever.
+func (m *FrozenIntMap) Get(key uint64) (value uint64, ok bool) {
+	if key == FREE_KEY {
+		return m.zeroVal, m.hasZero
+	}
+
+	// manually inline phiMix to help inlining
+	h := key * INT_PHI
+	ptr := h ^ (h >> 16)
+
+	for i := uint64(0); i <= m.mask; i++ {
+		ptr &= m.mask
+		e := (*entry[uint64, uint64])(unsafe.Pointer(uintptr(m.dptr) + uintptr(ptr)*unsafe.Sizeof(entry[uint64, uint64]{})))
+		if e.K == key {
+			return e.V, true
+		}
+		if e.K == FREE_KEY {
+			return
+		}
+		ptr += 1
+	}
+	return
+}
+
+// Has tells whether a key exists in the map.
+func (m *FrozenIntMap) Has(key uint64) bool {
+	_, ok := m.Get(key)
+	return ok
+}
+
+// All returns an iterator over key value pairs in the map,
+// in no particular order.
+func (m *FrozenIntMap) All() iter.Seq2[uint64, uint64] {
+	return func(yield func(uint64, uint64) bool) {
+		if m.hasZero {
+			if !yield(FREE_KEY, m.zeroVal) {
+				return
+			}
+		}
+		table := unsafe.Slice((*entry[uint64, uint64])(m.dptr), m.mask+1)
+		for _, e := range table {
+			if e.K != FREE_KEY {
+				if !yield(e.K, e.V) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Close releases the underlying memory mapping if the map is opened by
+// OpenFrozenIntMap, the map must not be used after closing.
+func (m *FrozenIntMap) Close() error {
+	if m.close == nil {
+		return nil
+	}
+	err := m.close()
+	m.close = nil
+	m.dptr, m.mask, m.size = nil, 0, 0
+	return err
+}
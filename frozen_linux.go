@@ -0,0 +1,42 @@
+//go:build linux
+
+package phimap
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// OpenFrozenIntMap maps the file written by WriteFrozenIntMap into memory,
+// the returned map uses the hash table in place.
+// The file must not be modified while the map is in use,
+// call Close to unmap the file.
+func OpenFrozenIntMap(path string) (*FrozenIntMap, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := fi.Size()
+	if size < frozenHeaderSize || int64(int(size)) != size {
+		return nil, fmt.Errorf("phimap: invalid frozen map file size %d", size)
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("phimap: mmap: %w", err)
+	}
+	m, err := LoadFrozenIntMap(data)
+	if err != nil {
+		syscall.Munmap(data)
+		return nil, err
+	}
+	m.close = func() error {
+		return syscall.Munmap(data)
+	}
+	return m, nil
+}
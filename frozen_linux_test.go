@@ -0,0 +1,30 @@
+//go:build linux
+
+package phimap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenFrozenIntMap(t *testing.T) {
+	m := NewIntIntMap()
+	for i := 0; i < 10000; i++ {
+		m.Set(uint64(i)*64, uint64(i)) // including key 0
+	}
+	path := filepath.Join(t.TempDir(), "frozen.bin")
+	f, err := os.Create(path)
+	assertEqual(t, nil, err)
+	assertEqual(t, nil, WriteFrozenIntMap(f, m))
+	assertEqual(t, nil, f.Close())
+
+	fm, err := OpenFrozenIntMap(path)
+	assertEqual(t, nil, err)
+	assertFrozenIntMap(t, m, fm)
+	assertEqual(t, nil, fm.Close())
+
+	assertEqual(t, nil, os.WriteFile(path, []byte("invalid"), 0o644))
+	_, err = OpenFrozenIntMap(path)
+	assertEqual(t, true, err != nil)
+}
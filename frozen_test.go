@@ -0,0 +1,107 @@
+package phimap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+)
+
+func TestFrozenIntMap(t *testing.T) {
+	m := NewIntIntMap()
+	for i := 0; i < 10000; i++ {
+		m.Set(uint64(i)*64, uint64(i)) // including key 0
+	}
+	var buf bytes.Buffer
+	assertEqual(t, nil, WriteFrozenIntMap(&buf, m))
+
+	fm, err := LoadFrozenIntMap(buf.Bytes())
+	assertEqual(t, nil, err)
+	assertFrozenIntMap(t, m, fm)
+	assertEqual(t, nil, fm.Close())
+
+	data := buf.Bytes()
+	_, err = LoadFrozenIntMap(data[:len(data)-8])
+	assertEqual(t, true, err != nil)
+	slots := (len(data) - frozenHeaderSize) / 16
+	for _, tc := range []struct {
+		name  string
+		field int
+		value uint64
+	}{
+		{"full table", 3, uint64(slots)},
+		// key 0 is not in the table
+		{"count over threshold", 3, uint64(calcThreshold(slots, maxFillFactor)) + 2},
+		{"invalid hasZero", 4, 2},
+	} {
+		_, err = LoadFrozenIntMap(withFrozenHeader(data, tc.field, tc.value))
+		if errString(err) != "phimap: invalid frozen map data" {
+			t.Errorf("%s: got error %v", tc.name, err)
+		}
+	}
+	_, err = LoadFrozenIntMap(withFrozenHeader(data, 3, uint64(calcThreshold(slots, maxFillFactor))+1))
+	assertEqual(t, nil, err)
+
+	// An empty map without a table, which has zero slots.
+	empty := withFrozenHeader(data[:frozenHeaderSize], 2, ^uint64(0))
+	empty = withFrozenHeader(empty, 3, 0)
+	empty = withFrozenHeader(empty, 4, 0)
+	_, err = LoadFrozenIntMap(empty)
+	assertEqual(t, "phimap: invalid frozen map data", errString(err))
+	corrupted := bytes.Clone(data)
+	corrupted[8*4] ^= 1
+	_, err = LoadFrozenIntMap(corrupted)
+	assertEqual(t, "phimap: frozen map header checksum mismatch", errString(err))
+
+	// A table without free slot is not verified, but lookups stop.
+	full := bytes.Clone(data)
+	for i := frozenHeaderSize; i < len(full); i += 16 {
+		binary.LittleEndian.PutUint64(full[i:], uint64(i))
+	}
+	fm, err = LoadFrozenIntMap(full)
+	assertEqual(t, nil, err)
+	_, ok := fm.Get(12345)
+	assertEqual(t, false, ok)
+	v, ok := fm.Get(0)
+	assertEqual(t, true, ok)
+	assertEqual(t, uint64(0), v)
+
+	data[4] = frozenVersion + 1
+	_, err = LoadFrozenIntMap(data)
+	assertEqual(t, true, err != nil)
+}
+
+// withFrozenHeader returns a copy of data with the header field i set to
+// x, the checksum is updated.
+func withFrozenHeader(data []byte, i int, x uint64) []byte {
+	data = bytes.Clone(data)
+	binary.LittleEndian.PutUint64(data[8+i*8:], x)
+	binary.LittleEndian.PutUint64(data[8+6*8:], uint64(crc32.Checksum(data[:frozenHeaderSize-8], crc32c)))
+	return data
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func assertFrozenIntMap(t *testing.T, m *IntIntMap, fm *FrozenIntMap) {
+	t.Helper()
+	assertEqual(t, m.Size(), fm.Len())
+	for k := uint64(0); k < 20000*64; k += 32 {
+		want, wantOk := m.Get(k)
+		got, ok := fm.Get(k)
+		assertEqual(t, wantOk, ok)
+		assertEqual(t, want, got)
+		assertEqual(t, wantOk, fm.Has(k))
+	}
+	n := 0
+	for k, v := range fm.All() {
+		want, _ := m.Get(k)
+		assertEqual(t, want, v)
+		n++
+	}
+	assertEqual(t, m.Size(), n)
+}
@@ -1,5 +1,5 @@
 module github.com/jxskiss/phimap
 
-go 1.18
+go 1.23
 
 retract v0.1.0
@@ -0,0 +1,82 @@
+package phimap
+
+import (
+	"math/bits"
+	"math/rand/v2"
+)
+
+// Hasher hashes an integer key to a 64 bits value, seed is the per-map
+// seed of a HashMap.
+//
+// The low bits of the result are used to locate a key in the hash table,
+// a Hasher must mix the key's high bits into the low bits, unless the
+// keys are known to be well-distributed in the low bits.
+type Hasher func(key, seed uint64) uint64
+
+// IdentityHasher returns key ^ seed.
+// It is the fastest, and it is suitable for keys which are already
+// well-distributed in the low bits, or which are sequential.
+// It performs badly with aligned keys, e.g. pointers.
+func IdentityHasher(key, seed uint64) uint64 {
+	return key ^ seed
+}
+
+// FibonacciHasher is the Fibonacci hashing which PhiMap uses,
+// it multiplies the key with INT_PHI.
+// It is fast and suitable for most keys, but keys which only differ
+// in high bits collide.
+func FibonacciHasher(key, seed uint64) uint64 {
+	return phiMix(key ^ seed)
+}
+
+// Murmur3Hasher is the 64 bits finalizer of MurmurHash3.
+// It mixes all bits of the key, it is suitable for clustered keys.
+func Murmur3Hasher(key, seed uint64) uint64 {
+	return fmix64(key ^ seed)
+}
+
+// WyHasher follows wyhash's algorithm for 8 bytes input,
+// see https://github.com/wangyi-fudan/wyhash.
+// It is the strongest one of the builtin Hashers, combined with a random
+// seed, it resists hash-flooding from attacker-chosen keys.
+func WyHasher(key, seed uint64) uint64 {
+	a := bits.RotateLeft64(key, 32) ^ wyp1
+	b := key ^ seed
+	hi, lo := bits.Mul64(a, b)
+	return wyMix(lo^wyp0^8, hi^wyp1)
+}
+
+// Secrets from wyhash.
+const (
+	wyp0 = 0xa0761d6478bd642f
+	wyp1 = 0xe7037ed1a0b428db
+)
+
+func wyMix(a, b uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	return hi ^ lo
+}
+
+func fmix64(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// hashFunc returns the hash function configured by the hashing options.
+func (o *options) hashFunc() func(key uint64) uint64 {
+	seed := o.seed
+	if !o.fixedSeed {
+		seed = rand.Uint64()
+	}
+	hasher := o.hasher
+	if hasher == nil {
+		hasher = FibonacciHasher
+	}
+	return func(key uint64) uint64 {
+		return hasher(key, seed)
+	}
+}
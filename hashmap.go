@@ -0,0 +1,262 @@
+package phimap
+
+import (
+	"io"
+	"iter"
+)
+
+// HashMap is a variant of PhiMap which hashes keys with a per-map
+// random seed, and a pluggable Hasher, see WithSeed, WithHasher and
+// WithStrongHash.
+//
+// Choosing a Hasher according to the keys' distribution helps to
+// shorten probing chains, e.g. IdentityHasher for well-distributed keys,
+// Murmur3Hasher for highly clustered keys.
+//
+// With the default Fibonacci hashing, a predictable hash function,
+// attacker-chosen keys can collide deliberately to form long probing
+// chains and make the map very slow (hash-flooding).
+// HashMap with WithStrongHash is suitable for keys from untrusted input,
+// e.g. user IDs in a public API.
+// A seed alone only perturbs the Fibonacci hashing, keys which only
+// differ in high bits still collide.
+//
+// The cost is that Get, Has and Lookup call the hash function,
+// they are not inline-able as PhiMap's, use PhiMap for trusted keys.
+type HashMap[T any] struct {
+	m PhiMap[T]
+}
+
+// NewHashMap creates a new HashMap.
+func NewHashMap[T any](opts ...Option) *HashMap[T] {
+	o := newOptions(opts)
+	m := newPhiMap[T](o)
+	m.hashFn = o.hashFunc()
+	return &HashMap[T]{m: *m}
+}
+
+// initHash gives a zero HashMap, e.g. which is allocated by
+// encoding/json or declared as a variable to unmarshal into,
+// the default hash function with a random seed.
+func (m *HashMap[T]) initHash() {
+	if m.m.hashFn == nil {
+		m.m.hashFn = newOptions(nil).hashFunc()
+	}
+}
+
+// Size returns the size of the map.
+func (m *HashMap[T]) Size() int {
+	return m.m.Size()
+}
+
+// Get returns the value if the key is found, else it returns zero value of T.
+func (m *HashMap[T]) Get(key uint64) T {
+	val, _ := m.m.tab().lookup(key)
+	return val
+}
+
+// Lookup returns the value and true if the key is found,
+// else it returns zero value of T and false.
+func (m *HashMap[T]) Lookup(key uint64) (T, bool) {
+	return m.m.tab().lookup(key)
+}
+
+// Has tells whether a key exists in the map.
+func (m *HashMap[T]) Has(key uint64) bool {
+	_, ok := m.m.tab().lookup(key)
+	return ok
+}
+
+// Set adds or updates key with value to the map.
+func (m *HashMap[T]) Set(key uint64, val T) {
+	m.m.Set(key, val)
+}
+
+// GetOrSet returns the existing value for the key if present,
+// else it adds key with val to the map and returns val.
+// See PhiMap.GetOrSet for details.
+func (m *HashMap[T]) GetOrSet(key uint64, val T) (actual T, loaded bool) {
+	return m.m.GetOrSet(key, val)
+}
+
+// GetOrCompute returns the existing value for the key if present,
+// else it calls f to compute a value, adds it to the map and returns it.
+// See PhiMap.GetOrCompute for details.
+func (m *HashMap[T]) GetOrCompute(key uint64, f func() T) T {
+	return m.m.GetOrCompute(key, f)
+}
+
+// Update calls f with the current value of key and stores the result.
+// See PhiMap.Update for details.
+func (m *HashMap[T]) Update(key uint64, f func(old T, ok bool) (T, bool)) {
+	m.m.Update(key, f)
+}
+
+// Swap sets the value for a key and returns the previous value if any.
+// See PhiMap.Swap for details.
+func (m *HashMap[T]) Swap(key uint64, val T) (old T, ok bool) {
+	return m.m.Swap(key, val)
+}
+
+// GetPtr returns a pointer to the value of key in the hash table,
+// or nil if the key is not found.
+// See PhiMap.GetPtr for when the pointer becomes invalid.
+func (m *HashMap[T]) GetPtr(key uint64) *T {
+	return m.m.GetPtr(key)
+}
+
+// SetPtr returns a pointer to the value of key in the hash table,
+// a missing key is added with zero value of T.
+// See PhiMap.GetPtr for when the pointer becomes invalid.
+func (m *HashMap[T]) SetPtr(key uint64) (val *T, inserted bool) {
+	return m.m.SetPtr(key)
+}
+
+// Delete deletes an element from the map.
+func (m *HashMap[T]) Delete(key uint64) {
+	m.m.Delete(key)
+}
+
+// GetMany looks up keys in the map, the value of keys[i] is written to
+// out[i]. See PhiMap.GetMany for details.
+func (m *HashMap[T]) GetMany(keys []uint64, out []T) (found int) {
+	return m.m.GetMany(keys, out)
+}
+
+// SetMany adds or updates keys with vals to the map.
+// See PhiMap.SetMany for details.
+func (m *HashMap[T]) SetMany(keys []uint64, vals []T) {
+	m.m.SetMany(keys, vals)
+}
+
+// DeleteMany deletes keys from the map, it returns the number of keys
+// deleted. See PhiMap.DeleteMany for details.
+func (m *HashMap[T]) DeleteMany(keys []uint64) (deleted int) {
+	return m.m.DeleteMany(keys)
+}
+
+// DeleteFunc deletes all entries for which f returns true,
+// it returns the number of entries deleted.
+// See PhiMap.DeleteFunc for details.
+func (m *HashMap[T]) DeleteFunc(f func(k uint64, v T) bool) (deleted int) {
+	return m.m.DeleteFunc(f)
+}
+
+// Retain deletes all entries for which f returns false,
+// it returns the number of entries deleted.
+// See PhiMap.DeleteFunc for details.
+func (m *HashMap[T]) Retain(f func(k uint64, v T) bool) (deleted int) {
+	return m.m.Retain(f)
+}
+
+// Reserve grows the map if needed, to make sure that another n entries
+// can be added to the map without growing again.
+func (m *HashMap[T]) Reserve(n int) {
+	m.m.Reserve(n)
+}
+
+// Compact shrinks the map to the smallest capacity which can hold
+// the entries in the map.
+func (m *HashMap[T]) Compact() {
+	m.m.Compact()
+}
+
+// Clear deletes all entries from the map, it keeps the allocated
+// capacity to be reused.
+func (m *HashMap[T]) Clear() {
+	m.m.Clear()
+}
+
+// Reset deletes all entries from the map, and reallocates the map
+// to be able to hold capacity entries without growing.
+func (m *HashMap[T]) Reset(capacity int) {
+	m.m.Reset(capacity)
+}
+
+// Copy returns a copy of a HashMap, which uses the same hash function.
+// If the map's size reaches the threshold, the new map's capacity
+// will be twice of the old.
+func (m *HashMap[T]) Copy() *HashMap[T] {
+	return &HashMap[T]{m: *m.m.Copy()}
+}
+
+// CopyWithCapacity returns a copy of a HashMap, which can hold another
+// n entries without growing.
+func (m *HashMap[T]) CopyWithCapacity(n int) *HashMap[T] {
+	return &HashMap[T]{m: *m.m.CopyWithCapacity(n)}
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+// The hash function is not serialized.
+// See PhiMap.MarshalBinary for the supported value types.
+func (m *HashMap[T]) MarshalBinary() ([]byte, error) {
+	return m.m.MarshalBinary()
+}
+
+// MarshalBinaryWith is same as MarshalBinary, but it encodes values
+// with codec.
+func (m *HashMap[T]) MarshalBinaryWith(codec ValueCodec[T]) ([]byte, error) {
+	return m.m.MarshalBinaryWith(codec)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+// The map keeps its own hash function, a zero HashMap gets a random seed.
+// See PhiMap.UnmarshalBinary for details.
+func (m *HashMap[T]) UnmarshalBinary(data []byte) error {
+	m.initHash()
+	return m.m.UnmarshalBinary(data)
+}
+
+// UnmarshalBinaryWith is same as UnmarshalBinary, but it decodes values
+// with codec.
+func (m *HashMap[T]) UnmarshalBinaryWith(data []byte, codec ValueCodec[T]) error {
+	m.initHash()
+	return m.m.UnmarshalBinaryWith(data, codec)
+}
+
+// MarshalJSON implements json.Marshaler.
+// See PhiMap.MarshalJSON for details.
+func (m *HashMap[T]) MarshalJSON() ([]byte, error) {
+	return m.m.MarshalJSON()
+}
+
+// EncodeJSON writes the map to w as a JSON object.
+// See PhiMap.EncodeJSON for details.
+func (m *HashMap[T]) EncodeJSON(w io.Writer, sortKeys bool) error {
+	return m.m.EncodeJSON(w, sortKeys)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+// The map keeps its own hash function, a zero HashMap gets a random seed.
+// See PhiMap.UnmarshalJSON for details.
+func (m *HashMap[T]) UnmarshalJSON(data []byte) error {
+	m.initHash()
+	return m.m.UnmarshalJSON(data)
+}
+
+// Keys returns all keys in the map, in no particular order.
+func (m *HashMap[T]) Keys() []uint64 {
+	return m.m.Keys()
+}
+
+// Items returns all key value entries in the map, in no particular order.
+func (m *HashMap[T]) Items() []Entry {
+	return m.m.Items()
+}
+
+// All returns an iterator over key value pairs in the map,
+// in no particular order.
+// See PhiMap.All for the behavior when the map is modified during iteration.
+func (m *HashMap[T]) All() iter.Seq2[uint64, T] {
+	return m.m.All()
+}
+
+// KeysSeq returns an iterator over keys in the map, in no particular order.
+func (m *HashMap[T]) KeysSeq() iter.Seq[uint64] {
+	return m.m.KeysSeq()
+}
+
+// Values returns an iterator over values in the map, in no particular order.
+func (m *HashMap[T]) Values() iter.Seq[T] {
+	return m.m.Values()
+}
@@ -0,0 +1,168 @@
+package phimap
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestHashMap(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []Option
+	}{
+		{"random seed", nil},
+		{"fixed seed", []Option{WithSeed(12345)}},
+		{"strong hash", []Option{WithStrongHash()}},
+		{"strong hash fixed seed", []Option{WithStrongHash(), WithSeed(12345)}},
+		{"identity", []Option{WithHasher(IdentityHasher)}},
+		{"fibonacci", []Option{WithHasher(FibonacciHasher)}},
+		{"murmur3", []Option{WithHasher(Murmur3Hasher)}},
+		{"wyhash", []Option{WithHasher(WyHasher)}},
+		{"custom", []Option{WithHasher(func(key, seed uint64) uint64 {
+			return (key ^ seed) * 0xff51afd7ed558ccd
+		})}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := NewHashMap[int](tc.opts...)
+			ref := make(map[uint64]int)
+			rnd := rand.New(rand.NewSource(1))
+			for i := 0; i < 100000; i++ {
+				k := uint64(rnd.Intn(20000))
+				switch rnd.Intn(3) {
+				case 0, 1:
+					m.Set(k, i)
+					ref[k] = i
+				case 2:
+					m.Delete(k)
+					delete(ref, k)
+				}
+			}
+			assertEqual(t, len(ref), m.Size())
+			for k := uint64(0); k < 20000; k++ {
+				want, wantOk := ref[k]
+				got, ok := m.Lookup(k)
+				assertEqual(t, wantOk, ok)
+				assertEqual(t, want, got)
+				assertEqual(t, want, m.Get(k))
+				assertEqual(t, wantOk, m.Has(k))
+			}
+
+			n := 0
+			for k, v := range m.All() {
+				assertEqual(t, ref[k], v)
+				n++
+			}
+			assertEqual(t, len(ref), n)
+			assertEqual(t, len(ref), len(m.Keys()))
+
+			m2 := m.CopyWithCapacity(100000)
+			for k, v := range ref {
+				assertEqual(t, v, m2.Get(k))
+			}
+			m.Compact()
+			for k, v := range ref {
+				assertEqual(t, v, m.Get(k))
+			}
+		})
+	}
+}
+
+func TestHashMap_Seed(t *testing.T) {
+	keys := func(m *HashMap[int]) []uint64 {
+		for i := 1; i <= 100; i++ {
+			m.Set(uint64(i), i)
+		}
+		return m.Keys()
+	}
+	k1 := keys(NewHashMap[int](WithStrongHash(), WithSeed(1)))
+	k2 := keys(NewHashMap[int](WithStrongHash(), WithSeed(1)))
+	k3 := keys(NewHashMap[int](WithStrongHash(), WithSeed(2)))
+	same := func(a, b []uint64) bool {
+		for i := range a {
+			if a[i] != b[i] {
+				return false
+			}
+		}
+		return true
+	}
+	assertEqual(t, true, same(k1, k2))
+	assertEqual(t, false, same(k1, k3))
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic for hashing options given to NewPhiMap")
+		}
+	}()
+	NewPhiMap[int](WithSeed(1))
+}
+
+func TestHashers(t *testing.T) {
+	assertEqual(t, uint64(0xb456bcfc34c2cb2c), Murmur3Hasher(1, 0))
+	assertEqual(t, uint64(0), Murmur3Hasher(0, 0))
+	assertEqual(t, uint64(123), IdentityHasher(123, 0))
+	assertEqual(t, phiMix(123), FibonacciHasher(123, 0))
+	assertEqual(t, WyHasher(123, 1), WyHasher(123, 1))
+	if WyHasher(123, 1) == WyHasher(123, 2) {
+		t.Errorf("WyHasher ignores seed")
+	}
+
+	// Clustered keys which only differ in high bits.
+	keys := make([]uint64, 2000)
+	for i := range keys {
+		keys[i] = uint64(i+1) << 40
+	}
+	for _, h := range []Hasher{Murmur3Hasher, WyHasher} {
+		m := NewHashMap[int](WithHasher(h))
+		for i, k := range keys {
+			m.Set(k, i)
+		}
+		if probe := avgProbeLength(&m.m, keys); probe > 3 {
+			t.Errorf("average probe length too long: %.2f", probe)
+		}
+	}
+}
+
+// avgProbeLength returns the average number of slots to probe to find
+// the keys in m.
+func avgProbeLength[T any](m *PhiMap[T], keys []uint64) float64 {
+	total := 0
+	for _, key := range keys {
+		ptr := phiMix(key)
+		if m.hashFn != nil {
+			ptr = m.hashFn(key)
+		}
+		for n := 1; ; n++ {
+			ptr &= m.mask
+			if *m.tab().getK(ptr) == key {
+				total += n
+				break
+			}
+			ptr++
+		}
+	}
+	return float64(total) / float64(len(keys))
+}
+
+func TestHashMap_Flooding(t *testing.T) {
+	// Keys which only differ in high bits collide with Fibonacci hashing.
+	keys := make([]uint64, 2000)
+	for i := range keys {
+		keys[i] = uint64(i+1) << 40
+	}
+
+	pm := NewPhiMap[int]()
+	hm := NewHashMap[int](WithStrongHash())
+	for i, k := range keys {
+		pm.Set(k, i)
+		hm.Set(k, i)
+	}
+	pmProbe := avgProbeLength(pm, keys)
+	hmProbe := avgProbeLength(&hm.m, keys)
+	t.Logf("average probe length: PhiMap= %.2f, HashMap= %.2f", pmProbe, hmProbe)
+	if pmProbe < 100 {
+		t.Errorf("expected the keys to collide in PhiMap")
+	}
+	if hmProbe > 3 {
+		t.Errorf("average probe length too long in HashMap: %.2f", hmProbe)
+	}
+}
@@ -0,0 +1,193 @@
+package phimap
+
+import "iter"
+
+// IntIntMap is a map from uint64 to uint64, e.g. IDs to indices,
+// or counters.
+//
+// Its hash table holds no pointers, the garbage collector never scans
+// it.
+type IntIntMap struct {
+	m PhiMap[uint64]
+}
+
+// NewIntIntMap creates a new IntIntMap.
+func NewIntIntMap(opts ...Option) *IntIntMap {
+	return &IntIntMap{m: *NewPhiMap[uint64](opts...)}
+}
+
+// Size returns the size of the map.
+func (m *IntIntMap) Size() int {
+	return m.m.Size()
+}
+
+// Get returns the value and true if the key is found,
+// else it returns 0 and false.
+// It is optimized to be inline-able.
+func (m *IntIntMap) Get(key uint64) (value uint64, ok bool) {
+	value, ok = m.m.Lookup(key)
+	return
+}
+
+// Has tells whether a key exists in the map.
+// It is optimized to be inline-able.
+func (m *IntIntMap) Has(key uint64) bool {
+	return m.m.Has(key)
+}
+
+// Set adds or updates key with value to the map.
+func (m *IntIntMap) Set(key, val uint64) {
+	m.m.Set(key, val)
+}
+
+// Add adds delta to the value of key, a missing key is added with
+// value delta. It returns the new value.
+// The value wraps around on overflow, to subtract x, add ^(x-1).
+func (m *IntIntMap) Add(key, delta uint64) uint64 {
+	return addInt(m.m.tab(), key, delta)
+}
+
+// addInt adds delta to the value of key in t, it probes the hash table
+// only once. See IntIntMap.Add for details.
+func addInt[K, V Integer](t *table[K, V], key K, delta V) V {
+	if key == FREE_KEY {
+		t.setZero(t.zeroVal+delta, true)
+		return t.zeroVal
+	}
+	ptr, found := t.findSlot(key)
+	if found {
+		v := t.getV(ptr)
+		*v += delta
+		return *v
+	}
+	t.insertAt(ptr, key, delta)
+	return delta
+}
+
+// Delete deletes an element from the map.
+func (m *IntIntMap) Delete(key uint64) {
+	m.m.Delete(key)
+}
+
+// Reserve grows the map if needed, to make sure that another n entries
+// can be added to the map without growing again.
+func (m *IntIntMap) Reserve(n int) {
+	m.m.Reserve(n)
+}
+
+// Compact shrinks the map to the smallest capacity which can hold
+// the entries in the map.
+func (m *IntIntMap) Compact() {
+	m.m.Compact()
+}
+
+// Clear deletes all entries from the map, it keeps the allocated
+// capacity to be reused.
+func (m *IntIntMap) Clear() {
+	m.m.Clear()
+}
+
+// Copy returns a copy of an IntIntMap.
+// If the map's size reaches the threshold, the new map's capacity
+// will be twice of the old.
+func (m *IntIntMap) Copy() *IntIntMap {
+	return &IntIntMap{m: *m.m.Copy()}
+}
+
+// Keys returns all keys in the map, in no particular order.
+func (m *IntIntMap) Keys() []uint64 {
+	return m.m.Keys()
+}
+
+// All returns an iterator over key value pairs in the map,
+// in no particular order.
+// See PhiMap.All for the behavior when the map is modified during iteration.
+func (m *IntIntMap) All() iter.Seq2[uint64, uint64] {
+	return m.m.All()
+}
+
+// IntIntMap32 is a map from uint32 to uint32, an entry takes 8 bytes,
+// half of an IntIntMap's, thus the hash table is denser.
+//
+// It is an IntMap[uint32, uint32] with Add, its hash table holds no
+// pointers, the garbage collector never scans it.
+type IntIntMap32 struct {
+	m IntMap[uint32, uint32]
+}
+
+// NewIntIntMap32 creates a new IntIntMap32.
+func NewIntIntMap32(opts ...Option) *IntIntMap32 {
+	return &IntIntMap32{m: *NewIntMap[uint32, uint32](opts...)}
+}
+
+// Size returns the size of the map.
+func (m *IntIntMap32) Size() int {
+	return m.m.Size()
+}
+
+// Get returns the value and true if the key is found,
+// else it returns 0 and false.
+// It is optimized to be inline-able.
+func (m *IntIntMap32) Get(key uint32) (value uint32, ok bool) {
+	return m.m.Lookup(key)
+}
+
+// Has tells whether a key exists in the map.
+// It is optimized to be inline-able.
+func (m *IntIntMap32) Has(key uint32) bool {
+	return m.m.Has(key)
+}
+
+// Set adds or updates key with value to the map.
+func (m *IntIntMap32) Set(key, val uint32) {
+	m.m.Set(key, val)
+}
+
+// Add adds delta to the value of key, a missing key is added with
+// value delta. It returns the new value.
+// The value wraps around on overflow, to subtract x, add ^(x-1).
+func (m *IntIntMap32) Add(key, delta uint32) uint32 {
+	return addInt(m.m.tab(), key, delta)
+}
+
+// Delete deletes an element from the map.
+func (m *IntIntMap32) Delete(key uint32) {
+	m.m.Delete(key)
+}
+
+// Reserve grows the map if needed, to make sure that another n entries
+// can be added to the map without growing again.
+func (m *IntIntMap32) Reserve(n int) {
+	m.m.Reserve(n)
+}
+
+// Compact shrinks the map to the smallest capacity which can hold
+// the entries in the map.
+func (m *IntIntMap32) Compact() {
+	m.m.Compact()
+}
+
+// Clear deletes all entries from the map, it keeps the allocated
+// capacity to be reused.
+func (m *IntIntMap32) Clear() {
+	m.m.Clear()
+}
+
+// Copy returns a copy of an IntIntMap32.
+// If the map's size reaches the threshold, the new map's capacity
+// will be twice of the old.
+func (m *IntIntMap32) Copy() *IntIntMap32 {
+	return &IntIntMap32{m: *m.m.Copy()}
+}
+
+// Keys returns all keys in the map, in no particular order.
+func (m *IntIntMap32) Keys() []uint32 {
+	return m.m.Keys()
+}
+
+// All returns an iterator over key value pairs in the map,
+// in no particular order.
+// See PhiMap.All for the behavior when the map is modified during iteration.
+func (m *IntIntMap32) All() iter.Seq2[uint32, uint32] {
+	return m.m.All()
+}
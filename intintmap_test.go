@@ -0,0 +1,96 @@
+package phimap
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// intIntMap adapts IntIntMap and IntIntMap32 to testMap,
+// whose Get works as Lookup.
+type intIntMap[K Integer] struct {
+	intIntMapper[K]
+}
+
+type intIntMapper[K Integer] interface {
+	Size() int
+	Set(key, val K)
+	Delete(key K)
+	Get(key K) (K, bool)
+	Has(key K) bool
+}
+
+func (m intIntMap[K]) Lookup(key K) (K, bool) {
+	return m.Get(key)
+}
+
+func TestIntIntMap(t *testing.T) {
+	m := NewIntIntMap()
+	tm := intIntMap[uint64]{m}
+	ref := make(map[uint64]uint64)
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 10; i++ {
+		testMapOps(tm, ref, rnd, 10000, 20000)
+		for j := 0; j < 10000; j++ {
+			k := clusteredKey[uint64](rnd, 20000)
+			got := m.Add(k, 3)
+			ref[k] += 3
+			assertEqual(t, ref[k], got)
+		}
+	}
+	assertMapEqual(t, tm, ref, 20000)
+
+	// wraps around
+	assertEqual(t, uint64(3), m.Add(1, 3))
+	assertEqual(t, uint64(0), m.Add(1, ^uint64(3-1)))
+
+	m2 := m.Copy()
+	n := 0
+	for k, v := range m.All() {
+		got, _ := m2.Get(k)
+		assertEqual(t, got, v)
+		n++
+	}
+	assertEqual(t, m2.Size(), n)
+	assertEqual(t, n, len(m.Keys()))
+}
+
+func TestIntIntMap32(t *testing.T) {
+	m := NewIntIntMap32(WithAutoShrink(0.1))
+	tm := intIntMap[uint32]{m}
+	ref := make(map[uint32]uint32)
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 10; i++ {
+		testMapOps(tm, ref, rnd, 10000, 20000)
+		for j := 0; j < 10000; j++ {
+			k := clusteredKey[uint32](rnd, 20000)
+			got := m.Add(k, 3)
+			ref[k] += 3
+			assertEqual(t, ref[k], got)
+		}
+	}
+	assertMapEqual(t, tm, ref, 20000)
+
+	m2 := m.Copy()
+	m.Compact()
+	keys := m.Keys()
+	assertEqual(t, len(ref), len(keys))
+	for _, k := range keys {
+		got, _ := m2.Get(k)
+		assertEqual(t, ref[k], got)
+	}
+
+	// Deleting all entries during iteration shrinks the map.
+	n := 0
+	for k, v := range m.All() {
+		assertEqual(t, ref[k], v)
+		m.Delete(k)
+		n++
+	}
+	assertEqual(t, len(ref), n)
+	assertEqual(t, 0, m.Size())
+	assertEqual(t, arraySize(initSize, fillFactor), len(m.m.data))
+
+	m2.Clear()
+	assertEqual(t, 0, m2.Size())
+	assertEqual(t, false, m2.Has(keys[0]))
+}
@@ -0,0 +1,183 @@
+package phimap
+
+import (
+	"iter"
+	"unsafe"
+)
+
+// Integer is a constraint that permits any integer type,
+// which can be used as key of an IntMap.
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// IntMap is a variant of PhiMap which accepts any integer type as key,
+// e.g. int32 enum codes, int64 timestamps, or uintptrs, without
+// converting them to uint64.
+//
+// Entries are sized by the key type, a narrow key such as uint32 makes
+// the hash table denser, e.g. an IntMap[uint32, uint32] entry takes
+// 8 bytes, while a PhiMap[uint32] entry takes 16 bytes.
+//
+// Keys are hashed by phiMix as uint64, negative keys are sign-extended,
+// they are non-zero and never collide with FREE_KEY.
+// Key 0 is stored aside from the hash table as PhiMap does.
+type IntMap[K Integer, T any] table[K, T]
+
+// NewIntMap creates a new IntMap.
+func NewIntMap[K Integer, T any](opts ...Option) *IntMap[K, T] {
+	m := &IntMap[K, T]{}
+	m.tab().init(newMapOptions(opts))
+	return m
+}
+
+// tab returns the hash table of the map.
+func (m *IntMap[K, T]) tab() *table[K, T] {
+	return (*table[K, T])(m)
+}
+
+// Size returns the size of the map.
+func (m *IntMap[K, T]) Size() int {
+	return m.size
+}
+
+// Get returns the value if the key is found, else it returns zero value of T.
+// It is optimized to be inline-able.
+func (m *IntMap[K, T]) Get(key K) (value T) {
+	// manually inline phiMix to help inlining
+	h := uint64(key) * INT_PHI
+	ptr := h ^ (h >> 16)
+
+	for {
+		ptr &= m.mask
+		// manually inline m.getK and m.getV
+		e := (*entry[K, T])(unsafe.Pointer(uintptr(m.dptr) + uintptr(ptr)*unsafe.Sizeof(entry[K, T]{})))
+		if e.K == key {
+			if key == 0 {
+				return m.zeroVal
+			}
+			return e.V
+		}
+		if e.K == 0 {
+			return
+		}
+		ptr += 1
+	}
+}
+
+// Lookup returns the value and true if the key is found,
+// else it returns zero value of T and false.
+// It is optimized to be inline-able.
+func (m *IntMap[K, T]) Lookup(key K) (value T, ok bool) {
+	// manually inline phiMix to help inlining
+	h := uint64(key) * INT_PHI
+	ptr := h ^ (h >> 16)
+
+	for {
+		ptr &= m.mask
+		// manually inline m.getK and m.getV
+		e := (*entry[K, T])(unsafe.Pointer(uintptr(m.dptr) + uintptr(ptr)*unsafe.Sizeof(entry[K, T]{})))
+		if e.K == key {
+			if key == 0 {
+				return m.zeroVal, m.hasZero
+			}
+			return e.V, true
+		}
+		if e.K == 0 {
+			return
+		}
+		ptr += 1
+	}
+}
+
+// Has tells whether a key exists in the map.
+// It is optimized to be inline-able.
+func (m *IntMap[K, T]) Has(key K) bool {
+	// manually inline phiMix to help inlining
+	h := uint64(key) * INT_PHI
+	ptr := h ^ (h >> 16)
+
+	for {
+		ptr &= m.mask
+		// manually inline m.getK and m.getV
+		k := (*entry[K, T])(unsafe.Pointer(uintptr(m.dptr) + uintptr(ptr)*unsafe.Sizeof(entry[K, T]{}))).K
+		if k == key {
+			return key != 0 || m.hasZero
+		}
+		if k == 0 {
+			return false
+		}
+		ptr += 1
+	}
+}
+
+// Set adds or updates key with value to the map.
+func (m *IntMap[K, T]) Set(key K, val T) {
+	m.tab().set(key, val)
+}
+
+// Reserve grows the map if needed, to make sure that another n entries
+// can be added to the map without growing again.
+func (m *IntMap[K, T]) Reserve(n int) {
+	m.tab().reserve(n)
+}
+
+// Clear deletes all entries from the map, it keeps the allocated
+// capacity to be reused.
+// Keys and values are zeroed, thus the values can be garbage collected.
+func (m *IntMap[K, T]) Clear() {
+	m.tab().clear()
+}
+
+// Reset deletes all entries from the map, and reallocates the map
+// to be able to hold capacity entries without growing.
+func (m *IntMap[K, T]) Reset(capacity int) {
+	m.tab().reset(capacity)
+}
+
+// Compact shrinks the map to the smallest capacity which can hold
+// the entries in the map.
+// It helps to release memory after deleting lots of entries.
+func (m *IntMap[K, T]) Compact() {
+	m.tab().compact()
+}
+
+// Delete deletes an element from the map.
+func (m *IntMap[K, T]) Delete(key K) {
+	m.tab().delete(key)
+}
+
+// Copy returns a copy of an IntMap, if the map's size reaches the
+// threshold, the new map's capacity will be twice of the old.
+func (m *IntMap[K, T]) Copy() *IntMap[K, T] {
+	return (*IntMap[K, T])(m.tab().clone())
+}
+
+// CopyWithCapacity returns a copy of an IntMap, which can hold another
+// n entries without growing.
+func (m *IntMap[K, T]) CopyWithCapacity(n int) *IntMap[K, T] {
+	return (*IntMap[K, T])(m.tab().cloneWithCapacity(n))
+}
+
+// Keys returns all keys in the map, in no particular order.
+func (m *IntMap[K, T]) Keys() []K {
+	return m.tab().keys()
+}
+
+// All returns an iterator over key value pairs in the map,
+// in no particular order.
+// See PhiMap.All for the behavior when the map is modified during iteration.
+func (m *IntMap[K, T]) All() iter.Seq2[K, T] {
+	return m.tab().iterate
+}
+
+// KeysSeq returns an iterator over keys in the map, in no particular order.
+func (m *IntMap[K, T]) KeysSeq() iter.Seq[K] {
+	return m.tab().keysSeq()
+}
+
+// Values returns an iterator over values in the map, in no particular order.
+func (m *IntMap[K, T]) Values() iter.Seq[T] {
+	return m.tab().values()
+}
@@ -0,0 +1,101 @@
+package phimap
+
+import (
+	"math/rand"
+	"testing"
+	"unsafe"
+)
+
+func testIntMap[K Integer](t *testing.T, randKey func(rnd *rand.Rand) K) {
+	m := NewIntMap[K, int](WithAutoShrink(0.1))
+	ref := make(map[K]int)
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 100000; i++ {
+		k := randKey(rnd)
+		switch rnd.Intn(3) {
+		case 0, 1:
+			m.Set(k, i)
+			ref[k] = i
+		case 2:
+			m.Delete(k)
+			delete(ref, k)
+		}
+	}
+	assertEqual(t, len(ref), m.Size())
+	for i := 0; i < 10000; i++ {
+		key := randKey(rnd)
+		want, wantOk := ref[key]
+		got, ok := m.Lookup(key)
+		assertEqual(t, wantOk, ok)
+		assertEqual(t, want, got)
+		assertEqual(t, want, m.Get(key))
+		assertEqual(t, wantOk, m.Has(key))
+	}
+
+	m2 := m.CopyWithCapacity(1000)
+	m3 := m.Copy()
+	m.Compact()
+	assertEqual(t, len(ref), len(m.Keys()))
+	for k, v := range ref {
+		assertEqual(t, v, m.Get(k))
+		assertEqual(t, v, m2.Get(k))
+		assertEqual(t, v, m3.Get(k))
+	}
+
+	n := 0
+	for k, v := range m.All() {
+		assertEqual(t, ref[k], v)
+		m.Delete(k)
+		n++
+	}
+	assertEqual(t, len(ref), n)
+	assertEqual(t, 0, m.Size())
+
+	m2.Clear()
+	assertEqual(t, 0, m2.Size())
+	m3.Reset(0)
+	assertEqual(t, 0, len(m3.Keys()))
+}
+
+func TestIntMap(t *testing.T) {
+	t.Run("int8", func(t *testing.T) {
+		// fills the key space, including key 0 and negative keys
+		testIntMap(t, func(rnd *rand.Rand) int8 { return int8(rnd.Intn(256)) })
+	})
+	t.Run("int32", func(t *testing.T) {
+		testIntMap(t, func(rnd *rand.Rand) int32 { return int32(rnd.Intn(20000) - 10000) })
+	})
+	t.Run("int64", func(t *testing.T) {
+		// timestamps, negative and positive
+		testIntMap(t, func(rnd *rand.Rand) int64 { return int64(rnd.Intn(20000)-10000) * 1e9 })
+	})
+	t.Run("uint32", func(t *testing.T) {
+		testIntMap(t, func(rnd *rand.Rand) uint32 { return uint32(rnd.Intn(20000)) * 64 })
+	})
+	t.Run("uintptr", func(t *testing.T) {
+		testIntMap(t, func(rnd *rand.Rand) uintptr { return 0xc0000000 + uintptr(rnd.Intn(20000))*64 })
+	})
+}
+
+func TestIntMap_EntrySize(t *testing.T) {
+	assertEqual(t, uintptr(8), unsafe.Sizeof(entry[uint32, uint32]{}))
+	assertEqual(t, uintptr(4), unsafe.Sizeof(entry[int16, int16]{}))
+	assertEqual(t, uintptr(16), unsafe.Sizeof(entry[int64, int64]{}))
+}
+
+func TestIntMap_NegativeKeys(t *testing.T) {
+	m := NewIntMap[int64, string]()
+	m.Set(-1, "a")
+	m.Set(0, "b")
+	m.Set(1, "c")
+	m.Set(-1<<63, "d")
+	assertEqual(t, 4, m.Size())
+	assertEqual(t, "a", m.Get(-1))
+	assertEqual(t, "b", m.Get(0))
+	assertEqual(t, "c", m.Get(1))
+	assertEqual(t, "d", m.Get(-1<<63))
+	m.Delete(-1)
+	assertEqual(t, false, m.Has(-1))
+	assertEqual(t, true, m.Has(0))
+	assertEqual(t, 3, m.Size())
+}
@@ -0,0 +1,198 @@
+package phimap
+
+import (
+	"iter"
+	"sync/atomic"
+)
+
+// All returns an iterator over key value pairs in the map,
+// in no particular order.
+//
+// The map may be modified during iteration:
+//
+//   - Deleting the entry currently being visited is safe, all the other
+//     entries are still produced exactly once.
+//   - Updating the value of an existing key is safe, the iterator
+//     produces the updated value if the entry has not been reached.
+//   - Entries added during iteration may or may not be produced.
+//     If the map is resized (by growing, Compact or auto shrinking),
+//     the iterator continues with the keys present before resizing,
+//     entries deleted after resizing are not produced.
+//   - Deleting an already produced entry, other than the current one,
+//     may move a not yet reached entry backward in its probing cluster,
+//     in which case that entry is not produced.
+//
+// No entry is produced more than once.
+func (m *PhiMap[T]) All() iter.Seq2[uint64, T] {
+	return m.tab().iterate
+}
+
+// KeysSeq returns an iterator over keys in the map, in no particular order.
+// See All for the behavior when the map is modified during iteration.
+func (m *PhiMap[T]) KeysSeq() iter.Seq[uint64] {
+	return m.tab().keysSeq()
+}
+
+// Values returns an iterator over values in the map, in no particular order.
+// See All for the behavior when the map is modified during iteration.
+func (m *PhiMap[T]) Values() iter.Seq[T] {
+	return m.tab().values()
+}
+
+// keysSeq implements KeysSeq of the maps.
+func (t *table[K, T]) keysSeq() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		t.iterate(func(k K, _ T) bool {
+			return yield(k)
+		})
+	}
+}
+
+// values implements Values of the maps.
+func (t *table[K, T]) values() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		t.iterate(func(_ K, v T) bool {
+			return yield(v)
+		})
+	}
+}
+
+// iterate implements All of the maps, see PhiMap.All for the behavior
+// when the map is modified during iteration.
+func (t *table[K, T]) iterate(yield func(k K, v T) bool) {
+	if t.hasZero {
+		if !yield(FREE_KEY, t.zeroVal) {
+			return
+		}
+	}
+
+	// The old hash table is kept by cur if the map is resized.
+	cur := *t
+	mask := cur.mask
+
+	// Start right after a free slot, thus no probing cluster wraps
+	// around the start position, shifting entries backward by Delete
+	// never moves an entry to a position which has been visited.
+	start := uint64(0)
+	for start < mask && *cur.getK(start) != FREE_KEY {
+		start++
+	}
+
+	resized := false
+	for i := uint64(1); i <= mask+1; i++ {
+		pos := (start + i) & mask
+		k := *cur.getK(pos)
+		if k == FREE_KEY {
+			continue
+		}
+		for {
+			v := *cur.getV(pos)
+			if resized {
+				var ok bool
+				if v, ok = t.lookup(k); !ok {
+					break
+				}
+			}
+			if !yield(k, v) {
+				return
+			}
+			if !resized && t.dptr != cur.dptr {
+				resized = true
+			}
+
+			// If the current entry is deleted, a not yet reached entry
+			// may be shifted into this slot, visit it again.
+			// The old table is not changed after the map is resized,
+			// but the deletion which triggers shrinking changes it.
+			if i == mask+1 {
+				break
+			}
+			next := *cur.getK(pos)
+			if next == FREE_KEY || next == k {
+				break
+			}
+			k = next
+		}
+	}
+}
+
+// All returns an iterator over key value pairs in the map,
+// in no particular order.
+//
+// Both the fast path and the slow path entries are produced,
+// no key is produced more than once.
+// Keys added concurrently during iteration may or may not be produced.
+func (m *TypeMap[T]) All() iter.Seq2[uintptr, T] {
+	return m.iterate
+}
+
+// KeysSeq returns an iterator over keys in the map, in no particular order.
+// See All for the details.
+func (m *TypeMap[T]) KeysSeq() iter.Seq[uintptr] {
+	return func(yield func(uintptr) bool) {
+		m.iterate(func(k uintptr, _ T) bool {
+			return yield(k)
+		})
+	}
+}
+
+// Values returns an iterator over values in the map, in no particular order.
+// See All for the details.
+func (m *TypeMap[T]) Values() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		m.iterate(func(_ uintptr, v T) bool {
+			return yield(v)
+		})
+	}
+}
+
+func (m *TypeMap[T]) iterate(yield func(k uintptr, v T) bool) {
+	// A published PhiMap is never changed, keys are only moved from
+	// the slow path to a newly published PhiMap by calibrate.
+	imap := (*PhiMap[T])(atomic.LoadPointer(&m.m))
+	for k, v := range imap.All() {
+		if !yield(uintptr(k), v) {
+			return
+		}
+	}
+
+	stopped := false
+	var slowKeys *PhiMap[struct{}]
+	m.m2.Range(func(key, value any) bool {
+		k := key.(uint64)
+		if imap.Has(k) {
+			return true
+		}
+		val := value.(*dirtyEntry).val.Load()
+		if val == nil { // not built or error occurred
+			return true
+		}
+		if slowKeys == nil {
+			slowKeys = NewPhiMap[struct{}]()
+		}
+		slowKeys.Set(k, struct{}{})
+		if !yield(uintptr(k), val.(T)) {
+			stopped = true
+			return false
+		}
+		return true
+	})
+	if stopped {
+		return
+	}
+
+	// Keys may be moved to a new PhiMap and deleted from the slow path
+	// before we see them, check the latest published PhiMap.
+	newMap := (*PhiMap[T])(atomic.LoadPointer(&m.m))
+	if newMap == imap {
+		return
+	}
+	for k, v := range newMap.All() {
+		if imap.Has(k) || (slowKeys != nil && slowKeys.Has(k)) {
+			continue
+		}
+		if !yield(uintptr(k), v) {
+			return
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package phimap
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"unsafe"
+)
+
+func TestPhiMap_All(t *testing.T) {
+	m := NewPhiMap[int]()
+	for i := 0; i < 1000; i++ {
+		m.Set(uint64(i), i*2)
+	}
+
+	got := make(map[uint64]int)
+	for k, v := range m.All() {
+		if _, ok := got[k]; ok {
+			t.Errorf("key %d is produced more than once", k)
+		}
+		got[k] = v
+	}
+	assertEqual(t, 1000, len(got))
+	for k, v := range got {
+		assertEqual(t, int(k)*2, v)
+	}
+
+	keys := make([]uint64, 0)
+	for k := range m.KeysSeq() {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	for i, k := range keys {
+		assertEqual(t, uint64(i), k)
+	}
+
+	sum := 0
+	for v := range m.Values() {
+		sum += v
+	}
+	assertEqual(t, 999*1000, sum)
+
+	count := 0
+	for range m.All() {
+		count++
+		if count == 10 {
+			break
+		}
+	}
+	assertEqual(t, 10, count)
+}
+
+func TestPhiMap_All_Modify(t *testing.T) {
+	newMap := func() *PhiMap[int] {
+		m := NewPhiMap[int]()
+		for i := 0; i < 1000; i++ {
+			m.Set(uint64(i), i)
+		}
+		return m
+	}
+
+	t.Run("delete current", func(t *testing.T) {
+		m := newMap()
+		seen := make(map[uint64]bool)
+		for k := range m.All() {
+			if seen[k] {
+				t.Errorf("key %d is produced more than once", k)
+			}
+			seen[k] = true
+			m.Delete(k)
+		}
+		assertEqual(t, 1000, len(seen))
+		assertEqual(t, 0, m.Size())
+	})
+
+	t.Run("update values", func(t *testing.T) {
+		m := newMap()
+		for k, v := range m.All() {
+			assertEqual(t, int(k), v)
+			m.Set(k, v+1)
+		}
+		for k, v := range m.All() {
+			assertEqual(t, int(k)+1, v)
+		}
+	})
+
+	t.Run("grow", func(t *testing.T) {
+		m := newMap()
+		seen := make(map[uint64]bool)
+		next := uint64(1000)
+		for k, v := range m.All() {
+			if seen[k] {
+				t.Errorf("key %d is produced more than once", k)
+			}
+			seen[k] = true
+			if k < 1000 {
+				assertEqual(t, int(k), v)
+			}
+			for j := 0; j < 10; j++ {
+				m.Set(next, int(next))
+				next++
+			}
+		}
+		for i := uint64(0); i < 1000; i++ {
+			if !seen[i] {
+				t.Errorf("key %d is not produced", i)
+			}
+		}
+	})
+
+	t.Run("delete current with auto shrink", func(t *testing.T) {
+		m := NewPhiMap[int](WithAutoShrink(0.1))
+		for i := 0; i < 10000; i++ {
+			m.Set(uint64(i), i)
+		}
+		seen := make(map[uint64]bool)
+		for k, v := range m.All() {
+			if seen[k] {
+				t.Errorf("key %d is produced more than once", k)
+			}
+			seen[k] = true
+			assertEqual(t, int(k), v)
+			m.Delete(k)
+		}
+		assertEqual(t, 10000, len(seen))
+		assertEqual(t, 0, m.Size())
+	})
+
+	t.Run("grow and delete", func(t *testing.T) {
+		m := newMap()
+		seen := make(map[uint64]bool)
+		grown := false
+		for k := range m.All() {
+			seen[k] = true
+			if !grown {
+				for j := uint64(1000); j < 5000; j++ {
+					m.Set(j, int(j))
+				}
+				for j := uint64(0); j < 1000; j++ {
+					if !seen[j] && j%2 == 0 {
+						m.Delete(j)
+					}
+				}
+				grown = true
+			}
+		}
+		for i := uint64(0); i < 1000; i++ {
+			if seen[i] != m.Has(i) {
+				t.Errorf("key %d: seen= %v, has= %v", i, seen[i], m.Has(i))
+			}
+		}
+	})
+}
+
+func TestTypeMap_All(t *testing.T) {
+	m := NewTypeMap[int]()
+	builder := func(x int) func() (int, error) {
+		return func() (int, error) { return x, nil }
+	}
+
+	want := make(map[uintptr]int)
+	for i, val := range testTypeMapValues1 {
+		typ := reflect.TypeOf(val)
+		_, _ = m.SetByType(typ, builder(i))
+		want[(*iface)(unsafe.Pointer(&typ)).data] = i
+	}
+	m.calibrate(true)
+	for i, val := range testTypeMapValues2 {
+		typ := reflect.TypeOf(val)
+		_, _ = m.SetByType(typ, builder(100+i))
+		want[(*iface)(unsafe.Pointer(&typ)).data] = 100 + i
+	}
+
+	got := make(map[uintptr]int)
+	for k, v := range m.All() {
+		if _, ok := got[k]; ok {
+			t.Errorf("key %x is produced more than once", k)
+		}
+		got[k] = v
+	}
+	assertEqual(t, len(want), len(got))
+	for k, v := range want {
+		assertEqual(t, v, got[k])
+	}
+
+	count := 0
+	for range m.KeysSeq() {
+		count++
+	}
+	assertEqual(t, len(want), count)
+
+	sum := 0
+	for v := range m.Values() {
+		sum += v
+	}
+	wantSum := 0
+	for _, v := range want {
+		wantSum += v
+	}
+	assertEqual(t, wantSum, sum)
+}
@@ -0,0 +1,135 @@
+package phimap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+)
+
+var (
+	_ json.Marshaler   = (*PhiMap[int])(nil)
+	_ json.Unmarshaler = (*PhiMap[int])(nil)
+)
+
+// jsonFlushSize is the buffered size that EncodeJSON writes out.
+const jsonFlushSize = 32 << 10
+
+// MarshalJSON implements json.Marshaler.
+//
+// The map is encoded as a JSON object with decimal string keys,
+// the same as map[uint64]T, keys are in no particular order.
+// Use EncodeJSON to encode keys in sorted order.
+func (m *PhiMap[T]) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := m.EncodeJSON(&buf, false); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// EncodeJSON writes the map to w as a JSON object, the same as
+// MarshalJSON. Entries are encoded one by one and written out in chunks,
+// the whole entries and output are never held in memory.
+//
+// If sortKeys is true, keys are written in ascending numeric order,
+// which gives stable output for diffing, at the cost of collecting and
+// sorting keys.
+func (m *PhiMap[T]) EncodeJSON(w io.Writer, sortKeys bool) error {
+	var err error
+	buf := make([]byte, 0, 1024)
+	buf = append(buf, '{')
+	first := true
+	encode := func(k uint64, v T) bool {
+		val, e := json.Marshal(v)
+		if e != nil {
+			err = e
+			return false
+		}
+		if !first {
+			buf = append(buf, ',')
+		}
+		first = false
+		buf = append(buf, '"')
+		buf = strconv.AppendUint(buf, k, 10)
+		buf = append(buf, '"', ':')
+		buf = append(buf, val...)
+		if len(buf) >= jsonFlushSize {
+			if _, err = w.Write(buf); err != nil {
+				return false
+			}
+			buf = buf[:0]
+		}
+		return true
+	}
+	if sortKeys {
+		for _, k := range slices.Sorted(m.KeysSeq()) {
+			v, _ := m.tab().lookup(k)
+			if !encode(k, v) {
+				return err
+			}
+		}
+	} else {
+		for k, v := range m.All() {
+			if !encode(k, v) {
+				return err
+			}
+		}
+	}
+	buf = append(buf, '}')
+	_, err = w.Write(buf)
+	return err
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+//
+// It decodes a JSON object with decimal string keys, the same as
+// map[uint64]T, and replaces all entries of the map with the decoded ones.
+// The map is not changed if data is JSON null, or on error.
+func (m *PhiMap[T]) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if tok != json.Delim('{') {
+		return fmt.Errorf("phimap: cannot unmarshal JSON %v into PhiMap", tok)
+	}
+
+	// Decode to a new hash table, the map is not changed on error.
+	// A zero PhiMap, e.g. a struct field, gets the default options.
+	tmp := &table[uint64, T]{
+		fillFactor:   m.fillFactor,
+		shrinkFactor: m.shrinkFactor,
+		hashFn:       m.hashFn,
+	}
+	if tmp.fillFactor == 0 {
+		tmp.fillFactor = fillFactor
+	}
+	tmp.alloc(arraySize(initSize, tmp.fillFactor))
+	for dec.More() {
+		tok, err = dec.Token()
+		if err != nil {
+			return err
+		}
+		k, err := strconv.ParseUint(tok.(string), 10, 64)
+		if err != nil {
+			return fmt.Errorf("phimap: invalid JSON key %q", tok)
+		}
+		var v T
+		if err = dec.Decode(&v); err != nil {
+			return err
+		}
+		tmp.set(k, v)
+	}
+	if _, err = dec.Token(); err != nil {
+		return err
+	}
+	m.tab().replace(tmp)
+	return nil
+}
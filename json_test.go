@@ -0,0 +1,120 @@
+package phimap
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPhiMap_MarshalJSON(t *testing.T) {
+	m := NewPhiMap[string]()
+	want := make(map[uint64]string)
+	for i := 0; i < 10000; i++ {
+		k := uint64(i) * 64 // including key 0
+		v := strings.Repeat("<v>", i%4)
+		m.Set(k, v)
+		want[k] = v
+	}
+
+	data, err := json.Marshal(m)
+	assertEqual(t, nil, err)
+	var got map[uint64]string
+	assertEqual(t, nil, json.Unmarshal(data, &got))
+	assertEqual(t, len(want), len(got))
+	for k, v := range want {
+		assertEqual(t, v, got[k])
+	}
+
+	// Keys are sorted numerically.
+	var buf bytes.Buffer
+	assertEqual(t, nil, m.EncodeJSON(&buf, true))
+	dec := json.NewDecoder(&buf)
+	dec.Token()
+	var prev uint64
+	for i := 0; dec.More(); i++ {
+		tok, _ := dec.Token()
+		k, _ := strconv.ParseUint(tok.(string), 10, 64)
+		assertEqual(t, true, i == 0 || k > prev)
+		prev = k
+		var v string
+		assertEqual(t, nil, dec.Decode(&v))
+		assertEqual(t, want[k], v)
+	}
+
+	small := NewPhiMap[int]()
+	for _, k := range []uint64{100, 9, 0, 20} {
+		small.Set(k, int(k))
+	}
+	buf.Reset()
+	assertEqual(t, nil, small.EncodeJSON(&buf, true))
+	assertEqual(t, `{"0":0,"9":9,"20":20,"100":100}`, buf.String())
+
+	empty, err := json.Marshal(NewPhiMap[int]())
+	assertEqual(t, nil, err)
+	assertEqual(t, "{}", string(empty))
+}
+
+func TestPhiMap_UnmarshalJSON(t *testing.T) {
+	var v struct {
+		A PhiMap[int]
+		B *HashMap[[]int]
+	}
+	data := `{"A": {"0": 1, "18446744073709551615": 2, "3": 3, "3": 4}, "B": {"1": [1, 2]}}`
+	assertEqual(t, nil, json.Unmarshal([]byte(data), &v))
+	assertEqual(t, 3, v.A.Size())
+	assertEqual(t, 1, v.A.Get(0))
+	assertEqual(t, 2, v.A.Get(1<<64-1))
+	assertEqual(t, 4, v.A.Get(3))
+	assertEqual(t, 2, len(v.B.Get(1)))
+	assertEqual(t, 2, v.B.Get(1)[1])
+	assertEqual(t, true, v.B.m.hashFn != nil)
+
+	m := NewPhiMap[int]()
+	m.Set(100, 100)
+	assertEqual(t, nil, m.UnmarshalJSON([]byte("null")))
+	assertEqual(t, 100, m.Get(100))
+	for _, data := range []string{
+		`[]`,
+		`{"-1": 1}`,
+		`{"a": 1}`,
+		`{"1": "x"}`,
+		`{"18446744073709551616": 1}`,
+	} {
+		err := json.Unmarshal([]byte(data), m)
+		assertEqual(t, true, err != nil)
+		assertEqual(t, 1, m.Size())
+		assertEqual(t, 100, m.Get(100))
+	}
+}
+
+type errWriter struct{ n int }
+
+func (w *errWriter) Write(p []byte) (int, error) {
+	w.n++
+	return 0, errors.New("write error")
+}
+
+func TestPhiMap_EncodeJSON(t *testing.T) {
+	m := NewPhiMap[[]byte]()
+	for i := 0; i < 10000; i++ {
+		m.Set(uint64(i), make([]byte, 10))
+	}
+
+	// Output is written in chunks.
+	w := &errWriter{}
+	assertEqual(t, true, m.EncodeJSON(w, false) != nil)
+	assertEqual(t, 1, w.n)
+
+	var buf bytes.Buffer
+	assertEqual(t, nil, m.EncodeJSON(&buf, false))
+	got := NewPhiMap[[]byte]()
+	assertEqual(t, nil, json.Unmarshal(buf.Bytes(), got))
+	assertEqual(t, m.Size(), got.Size())
+
+	bad := NewPhiMap[chan int]()
+	bad.Set(1, nil)
+	assertEqual(t, true, bad.EncodeJSON(&buf, true) != nil)
+}
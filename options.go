@@ -0,0 +1,136 @@
+package phimap
+
+import "fmt"
+
+const (
+	minFillFactor = 0.1
+	maxFillFactor = 0.95
+)
+
+// Option configures a map created by the constructors of this package.
+//
+// The hashing options WithSeed, WithHasher and WithStrongHash are only
+// supported by HashMap, the other constructors panic if they are given.
+type Option func(*options)
+
+type options struct {
+	fillFactor   float64
+	capacity     int
+	shrinkFactor float64
+
+	// hashing options, only for HashMap
+	hashing   bool
+	seed      uint64
+	fixedSeed bool
+	hasher    Hasher
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		fillFactor: fillFactor,
+		capacity:   initSize,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.shrinkFactor > o.fillFactor/4 {
+		panic(fmt.Sprintf("phimap: shrink factor %v is greater than a quarter of fill factor %v", o.shrinkFactor, o.fillFactor))
+	}
+	return o
+}
+
+// newMapOptions is newOptions for the maps which always use Fibonacci
+// hashing, it panics if a hashing option is given.
+func newMapOptions(opts []Option) *options {
+	o := newOptions(opts)
+	if o.hashing {
+		panic("phimap: hashing options are only supported by HashMap")
+	}
+	return o
+}
+
+// WithFillFactor sets the load factor of a PhiMap, the map grows when
+// the number of entries reaches capacity * f.
+// A lower value makes lookups faster, while a higher value uses
+// less memory.
+//
+// f must be in range [0.1, 0.95], else it panics.
+// The default value is 0.6.
+func WithFillFactor(f float64) Option {
+	if !(f >= minFillFactor && f <= maxFillFactor) {
+		panic(fmt.Sprintf("phimap: fill factor %v out of range [%v, %v]", f, minFillFactor, maxFillFactor))
+	}
+	return func(o *options) {
+		o.fillFactor = f
+	}
+}
+
+// WithInitialCapacity makes a PhiMap be able to hold n entries
+// without growing.
+// The default value is 32.
+func WithInitialCapacity(n int) Option {
+	return func(o *options) {
+		if n < 0 {
+			n = 0
+		}
+		o.capacity = n
+	}
+}
+
+// WithAutoShrink makes a PhiMap shrink automatically when the number
+// of entries drops below capacity * lowWater after deleting.
+// The map shrinks to the smallest capacity which can hold the entries,
+// but not smaller than the default initial capacity.
+//
+// lowWater must be greater than 0 and not greater than a quarter of
+// the fill factor, else it panics.
+// Growing or shrinking a map always leaves its occupancy between
+// fillFactor/2 and fillFactor, thus the gap to the shrinking and growing
+// thresholds is at least twice, it won't thrash around the boundaries.
+//
+// By default, a map never shrinks automatically, see also PhiMap.Compact.
+func WithAutoShrink(lowWater float64) Option {
+	if !(lowWater > 0 && lowWater <= maxFillFactor/4) {
+		panic(fmt.Sprintf("phimap: shrink factor %v out of range (0, %v]", lowWater, maxFillFactor/4))
+	}
+	return func(o *options) {
+		o.shrinkFactor = lowWater
+	}
+}
+
+// WithSeed sets the seed to hash keys of a HashMap.
+// By default, a HashMap uses a random seed, this option is useful
+// to get reproducible layout, e.g. in tests.
+//
+// It is only supported by HashMap.
+func WithSeed(seed uint64) Option {
+	return func(o *options) {
+		o.hashing = true
+		o.seed = seed
+		o.fixedSeed = true
+	}
+}
+
+// WithStrongHash makes a HashMap hash keys with a seeded mixer
+// based on wyhash, instead of the Fibonacci hashing.
+// It is slower, but resists hash-flooding from attacker-chosen keys.
+//
+// It is same as WithHasher(WyHasher), it is only supported by HashMap.
+func WithStrongHash() Option {
+	return WithHasher(WyHasher)
+}
+
+// WithHasher sets the Hasher to hash keys of a HashMap.
+// The builtin Hashers are IdentityHasher, FibonacciHasher, Murmur3Hasher
+// and WyHasher, a user-supplied function can also be used.
+// By default, a HashMap uses FibonacciHasher.
+//
+// It is only supported by HashMap.
+// PhiMap always uses Fibonacci hashing, which has an inline-able
+// fast path.
+func WithHasher(h Hasher) Option {
+	return func(o *options) {
+		o.hashing = true
+		o.hasher = h
+	}
+}
@@ -24,13 +24,14 @@ const (
 	fillFactor = 0.6
 	initSize   = 32
 	u64Size    = unsafe.Sizeof(uint64(0))
-	entrySize  = unsafe.Sizeof(Entry{})
 )
 
 // INT_PHI is for scrambling the keys.
 const INT_PHI = 0x9E3779B9
 
 // FREE_KEY is the 'free' key.
+// Key FREE_KEY is stored aside from the hash table, thus it can be used
+// as a normal key.
 const FREE_KEY = 0
 
 func phiMix(x uint64) uint64 {
@@ -66,38 +67,51 @@ func calcThreshold(capacity int, fillFactor float64) int {
 	return int(math.Floor(float64(capacity) * fillFactor))
 }
 
+// calcShrinkThreshold returns zero if a map of capacity won't shrink,
+// thus deleting won't bother trying.
+func calcShrinkThreshold(capacity int, fillFactor, shrinkFactor float64) int {
+	if capacity <= arraySize(initSize, fillFactor) {
+		return 0
+	}
+	return calcThreshold(capacity, shrinkFactor)
+}
+
 // Entry represents a key value pair in a PhiMap.
+//
+// It is returned by PhiMap.Items, values are boxed as interface,
+// which is kept for compatibility.
 type Entry struct {
 	K uint64
 	V any
 }
 
 // NewPhiMap creates a new PhiMap.
-func NewPhiMap[T any]() *PhiMap[T] {
-	capacity := arraySize(initSize, fillFactor)
-	threshold := calcThreshold(capacity, fillFactor)
-	mask := capacity - 1
-	data := make([]Entry, capacity)
-	return &PhiMap[T]{
-		data:       data,
-		dptr:       unsafe.Pointer(&data[0]),
-		fillFactor: fillFactor,
-		threshold:  threshold,
-		size:       0,
-		mask:       uint64(mask),
-	}
+func NewPhiMap[T any](opts ...Option) *PhiMap[T] {
+	return newPhiMap[T](newMapOptions(opts))
+}
+
+func newPhiMap[T any](o *options) *PhiMap[T] {
+	m := &PhiMap[T]{}
+	m.tab().init(o)
+	return m
+}
+
+// NewPhiMapWithCapacity creates a new PhiMap which can hold n entries
+// without growing.
+// It helps to avoid repeatedly rehashing when loading lots of entries.
+//
+// It is a shortcut for NewPhiMap[T](WithInitialCapacity(n)).
+func NewPhiMapWithCapacity[T any](n int) *PhiMap[T] {
+	return NewPhiMap[T](WithInitialCapacity(n))
 }
 
 // PhiMap is a fast hash table implementation which is suitable to
 // cache information that use integer keys.
-type PhiMap[T any] struct {
-	data []Entry
-	dptr unsafe.Pointer
-
-	fillFactor float64
-	threshold  int
-	size       int
-	mask       uint64
+type PhiMap[T any] table[uint64, T]
+
+// tab returns the hash table of the map.
+func (m *PhiMap[T]) tab() *table[uint64, T] {
+	return (*table[uint64, T])(m)
 }
 
 // Size returns the size of the map.
@@ -105,16 +119,6 @@ func (m *PhiMap[T]) Size() int {
 	return m.size
 }
 
-// getK helps to eliminate slice bounds checking
-func (m *PhiMap[T]) getK(ptr uint64) *uint64 {
-	return (*uint64)(unsafe.Pointer(uintptr(m.dptr) + uintptr(ptr)*entrySize))
-}
-
-// getV helps to eliminate slice bounds checking
-func (m *PhiMap[T]) getV(ptr uint64) *any {
-	return (*any)(unsafe.Pointer(uintptr(m.dptr) + uintptr(ptr)*entrySize + u64Size))
-}
-
 // Get returns the value if the key is found, else it returns zero value of T.
 // It is optimized to be inline-able.
 func (m *PhiMap[T]) Get(key uint64) (value T) {
@@ -125,12 +129,40 @@ func (m *PhiMap[T]) Get(key uint64) (value T) {
 	for {
 		ptr &= m.mask
 		// manually inline m.getK and m.getV
-		k := *(*uint64)(unsafe.Pointer(uintptr(m.dptr) + uintptr(ptr)*entrySize))
+		k := *(*uint64)(unsafe.Pointer(uintptr(m.dptr) + uintptr(ptr)*unsafe.Sizeof(entry[uint64, T]{})))
 		if k == key {
-			return (*(*any)(unsafe.Pointer(uintptr(m.dptr) + uintptr(ptr)*entrySize + u64Size))).(T)
+			if key == FREE_KEY {
+				return m.zeroVal
+			}
+			return *(*T)(unsafe.Pointer(uintptr(m.dptr) + uintptr(ptr)*unsafe.Sizeof(entry[uint64, T]{}) + u64Size))
 		}
-		if k == 0 {
-			return value
+		if k == FREE_KEY {
+			return
+		}
+		ptr += 1
+	}
+}
+
+// Lookup returns the value and true if the key is found,
+// else it returns zero value of T and false.
+// It is optimized to be inline-able.
+func (m *PhiMap[T]) Lookup(key uint64) (value T, ok bool) {
+	// manually inline phiMix to help inlining
+	h := key * INT_PHI
+	ptr := h ^ (h >> 16)
+
+	for {
+		ptr &= m.mask
+		// manually inline m.getK and m.getV
+		e := (*entry[uint64, T])(unsafe.Pointer(uintptr(m.dptr) + uintptr(ptr)*unsafe.Sizeof(entry[uint64, T]{})))
+		if e.K == key {
+			if key == FREE_KEY {
+				return m.zeroVal, m.hasZero
+			}
+			return e.V, true
+		}
+		if e.K == FREE_KEY {
+			return
 		}
 		ptr += 1
 	}
@@ -146,9 +178,9 @@ func (m *PhiMap[T]) Has(key uint64) bool {
 	for {
 		ptr &= m.mask
 		// manually inline m.getK and m.getV
-		k := *(*uint64)(unsafe.Pointer(uintptr(m.dptr) + uintptr(ptr)*entrySize))
+		k := *(*uint64)(unsafe.Pointer(uintptr(m.dptr) + uintptr(ptr)*unsafe.Sizeof(entry[uint64, T]{})))
 		if k == key {
-			return true
+			return key != FREE_KEY || m.hasZero
 		}
 		if k == 0 {
 			return false
@@ -159,159 +191,178 @@ func (m *PhiMap[T]) Has(key uint64) bool {
 
 // Set adds or updates key with value to the map.
 func (m *PhiMap[T]) Set(key uint64, val T) {
-	ptr := phiMix(key)
-	for {
-		ptr &= m.mask
-		k := *m.getK(ptr)
-		if k == FREE_KEY {
-			*m.getK(ptr) = key
-			*m.getV(ptr) = val
-			if m.size >= m.threshold {
-				m.rehash()
-			} else {
-				m.size++
-			}
-			return
-		}
-		if k == key {
-			*m.getV(ptr) = val
-			return
+	m.tab().set(key, val)
+}
+
+// GetOrSet returns the existing value for the key if present,
+// else it adds key with val to the map and returns val.
+// The loaded result is true if the value was loaded, false if added.
+// It probes the hash table only once.
+func (m *PhiMap[T]) GetOrSet(key uint64, val T) (actual T, loaded bool) {
+	t := m.tab()
+	if key == FREE_KEY {
+		if t.hasZero {
+			return t.zeroVal, true
 		}
-		ptr += 1
+		t.setZero(val, true)
+		return val, false
+	}
+	ptr, found := t.findSlot(key)
+	if found {
+		return *t.getV(ptr), true
 	}
+	t.insertAt(ptr, key, val)
+	return val, false
 }
 
-func (m *PhiMap[T]) rehash() {
-	newCapacity := len(m.data) * 2
-	m.threshold = calcThreshold(newCapacity, m.fillFactor)
-	m.mask = uint64(newCapacity - 1)
-
-	data := m.data
-	m.data = make([]Entry, newCapacity)
-	m.dptr = unsafe.Pointer(&m.data[0])
-	m.size = 0
-
-COPY:
-	for i := 0; i < len(data); i++ {
-		e := data[i]
-		if e.K == FREE_KEY {
-			continue
+// GetOrCompute returns the existing value for the key if present,
+// else it calls f to compute a value, adds it to the map and returns it.
+// It probes the hash table only once.
+//
+// f must not modify the map.
+func (m *PhiMap[T]) GetOrCompute(key uint64, f func() T) T {
+	t := m.tab()
+	if key == FREE_KEY {
+		if !t.hasZero {
+			t.setZero(f(), true)
 		}
+		return t.zeroVal
+	}
+	ptr, found := t.findSlot(key)
+	if found {
+		return *t.getV(ptr)
+	}
+	val := f()
+	t.insertAt(ptr, key, val)
+	return val
+}
 
-		// Manually inline the Set function to avoid unnecessary calculation.
-		ptr := phiMix(e.K)
-		for {
-			ptr &= m.mask
-			k := *m.getK(ptr)
-			if k == FREE_KEY {
-				*m.getK(ptr) = e.K
-				*m.getV(ptr) = e.V
-				m.size++
-				continue COPY
-			}
-			ptr += 1
+// Update calls f with the current value of key and whether key is
+// present, then stores the value returned by f.
+// If f returns false, key is deleted from the map if it is present.
+// It probes the hash table only once.
+//
+// f must not modify the map.
+func (m *PhiMap[T]) Update(key uint64, f func(old T, ok bool) (T, bool)) {
+	t := m.tab()
+	if key == FREE_KEY {
+		val, ok := f(t.zeroVal, t.hasZero)
+		t.setZero(val, ok)
+		return
+	}
+	var zero T
+	ptr, found := t.findSlot(key)
+	if found {
+		val, ok := f(*t.getV(ptr), true)
+		if ok {
+			*t.getV(ptr) = val
+		} else {
+			t.deleteAt(ptr)
 		}
+		return
 	}
+	if val, ok := f(zero, false); ok {
+		t.insertAt(ptr, key, val)
+	}
+}
+
+// Swap sets the value for a key and returns the previous value if any.
+// The ok result reports whether the key was present.
+// It probes the hash table only once.
+func (m *PhiMap[T]) Swap(key uint64, val T) (old T, ok bool) {
+	t := m.tab()
+	if key == FREE_KEY {
+		old, ok = t.zeroVal, t.hasZero
+		t.setZero(val, true)
+		return old, ok
+	}
+	ptr, found := t.findSlot(key)
+	if found {
+		v := t.getV(ptr)
+		old, *v = *v, val
+		return old, true
+	}
+	t.insertAt(ptr, key, val)
+	return old, false
+}
+
+// Reserve grows the map if needed, to make sure that another n entries
+// can be added to the map without growing again.
+func (m *PhiMap[T]) Reserve(n int) {
+	m.tab().reserve(n)
+}
+
+// Clear deletes all entries from the map, it keeps the allocated
+// capacity to be reused.
+// Keys and values are zeroed, thus the values can be garbage collected.
+func (m *PhiMap[T]) Clear() {
+	m.tab().clear()
+}
+
+// Reset deletes all entries from the map, and reallocates the map
+// to be able to hold capacity entries without growing.
+func (m *PhiMap[T]) Reset(capacity int) {
+	m.tab().reset(capacity)
+}
+
+// Compact shrinks the map to the smallest capacity which can hold
+// the entries in the map.
+// It helps to release memory after deleting lots of entries.
+func (m *PhiMap[T]) Compact() {
+	m.tab().compact()
 }
 
 // Delete deletes an element from the map.
 func (m *PhiMap[T]) Delete(key uint64) {
-	ptr := phiMix(key)
-	for {
-		ptr &= m.mask
-		k := *m.getK(ptr)
-		if k == key {
-			m.shiftKeys(ptr)
-			m.size--
-			return
-		}
-		if k == FREE_KEY {
-			return
-		}
-		ptr += 1
-	}
+	m.tab().delete(key)
 }
 
-func (m *PhiMap[T]) shiftKeys(pos uint64) uint64 {
-	var zero T
-	var k, last, slot uint64
-	for {
-		last = pos
-		pos = last + 1
-		for {
-			pos &= m.mask
-			k = *m.getK(pos)
-			if k == FREE_KEY {
-				*m.getK(last) = FREE_KEY
-				*m.getV(last) = zero
-				return last
-			}
+// DeleteFunc deletes all entries for which f returns true,
+// it returns the number of entries deleted.
+//
+// It sweeps the hash table once, an entry following deleted ones in
+// its probing cluster is moved to the first free slot from its home
+// slot, which is much faster than calling Delete for each entry.
+// The map shrinks at most once if auto shrinking is enabled.
+//
+// f must not modify the map.
+func (m *PhiMap[T]) DeleteFunc(f func(k uint64, v T) bool) (deleted int) {
+	return m.tab().deleteFunc(f)
+}
 
-			slot = phiMix(k) & m.mask
-			if last <= pos {
-				if last >= slot || slot > pos {
-					break
-				}
-			} else {
-				if last >= slot && slot > pos {
-					break
-				}
-			}
-			pos += 1
-		}
-		*(m.getK(last)) = *m.getK(pos)
-		*(m.getV(last)) = *m.getV(pos)
-	}
+// Retain deletes all entries for which f returns false, it keeps only
+// the entries for which f returns true.
+// It returns the number of entries deleted.
+// See DeleteFunc for details.
+func (m *PhiMap[T]) Retain(f func(k uint64, v T) bool) (deleted int) {
+	return m.DeleteFunc(func(k uint64, v T) bool {
+		return !f(k, v)
+	})
 }
 
 // Copy returns a copy of a PhiMap, if the map's size reaches the
 // threshold, the new map's capacity will be twice of the old.
 func (m *PhiMap[T]) Copy() *PhiMap[T] {
-	capacity := cap(m.data)
-	if m.size >= m.threshold {
-		capacity *= 2
-	}
-	mask := capacity - 1
-	data := make([]Entry, capacity)
-	newMap := &PhiMap[T]{
-		data:       data,
-		dptr:       unsafe.Pointer(&data[0]),
-		fillFactor: m.fillFactor,
-		threshold:  m.threshold,
-		size:       0,
-		mask:       uint64(mask),
-	}
-	for _, e := range m.data {
-		if e.K == FREE_KEY {
-			continue
-		}
-		newMap.Set(e.K, e.V.(T))
-	}
-	return newMap
+	return (*PhiMap[T])(m.tab().clone())
+}
+
+// CopyWithCapacity returns a copy of a PhiMap, which can hold another
+// n entries without growing.
+// It is useful when the caller knows how many keys it is about to insert
+// to the new map.
+func (m *PhiMap[T]) CopyWithCapacity(n int) *PhiMap[T] {
+	return (*PhiMap[T])(m.tab().cloneWithCapacity(n))
 }
 
 // Keys returns all keys in the map, in no particular order.
 func (m *PhiMap[T]) Keys() []uint64 {
-	keys := make([]uint64, 0, m.size+1)
-	data := m.data
-	for i := 0; i < len(data); i++ {
-		if data[i].K == FREE_KEY {
-			continue
-		}
-		keys = append(keys, data[i].K)
-	}
-	return keys
+	return m.tab().keys()
 }
 
 // Items returns all key value entries in the map, in no particular order.
+//
+// Values are boxed into Entry.V as interface for compatibility,
+// which allocates for most non-pointer types.
 func (m *PhiMap[T]) Items() []Entry {
-	items := make([]Entry, 0, m.size+1)
-	data := m.data
-	for i := 0; i < len(data); i++ {
-		if data[i].K == FREE_KEY {
-			continue
-		}
-		items = append(items, data[i])
-	}
-	return items
+	return m.tab().items()
 }
@@ -2,6 +2,7 @@ package phimap
 
 import (
 	"context"
+	"math/rand"
 	"strconv"
 	"testing"
 )
@@ -125,6 +126,399 @@ func TestPhiMapSimple(t *testing.T) {
 	}
 }
 
+func TestPhiMap_ZeroKey(t *testing.T) {
+	m := NewPhiMap[int]()
+
+	assertEqual(t, 0, m.Get(0))
+	assertEqual(t, false, m.Has(0))
+	m.Delete(0)
+	assertEqual(t, 0, m.Size())
+
+	m.Set(0, 100)
+	assertEqual(t, 100, m.Get(0))
+	assertEqual(t, true, m.Has(0))
+	assertEqual(t, 1, m.Size())
+
+	m.Set(0, 200)
+	assertEqual(t, 200, m.Get(0))
+	assertEqual(t, 1, m.Size())
+
+	// trigger several rounds of rehash
+	for i := 1; i <= 1000; i++ {
+		m.Set(uint64(i), i)
+	}
+	assertEqual(t, 1001, m.Size())
+	assertEqual(t, 200, m.Get(0))
+	assertEqual(t, true, m.Has(0))
+
+	keys := m.Keys()
+	assertEqual(t, 1001, len(keys))
+	hasZero := false
+	for _, k := range keys {
+		if k == 0 {
+			hasZero = true
+		}
+	}
+	assertEqual(t, true, hasZero)
+
+	items := m.Items()
+	assertEqual(t, 1001, len(items))
+	for _, kv := range items {
+		if kv.K == 0 {
+			assertEqual(t, 200, kv.V.(int))
+		} else {
+			assertEqual(t, int(kv.K), kv.V.(int))
+		}
+	}
+
+	m2 := m.Copy()
+	assertEqual(t, 1001, m2.Size())
+	assertEqual(t, 200, m2.Get(0))
+	assertEqual(t, true, m2.Has(0))
+
+	m.Delete(0)
+	assertEqual(t, 0, m.Get(0))
+	assertEqual(t, false, m.Has(0))
+	assertEqual(t, 1000, m.Size())
+	assertEqual(t, 1000, len(m.Keys()))
+	m.Delete(0)
+	assertEqual(t, 1000, m.Size())
+	for i := 1; i <= 1000; i++ {
+		assertEqual(t, i, m.Get(uint64(i)))
+	}
+
+	// the copy is not affected
+	assertEqual(t, 200, m2.Get(0))
+	assertEqual(t, 1001, m2.Size())
+}
+
+func TestPhiMap_Lookup(t *testing.T) {
+	m := NewPhiMap[int]()
+	for i := 0; i < 1000; i++ {
+		m.Set(uint64(i), i)
+	}
+	for i := 0; i < 1000; i++ {
+		got, ok := m.Lookup(uint64(i))
+		assertEqual(t, true, ok)
+		assertEqual(t, i, got)
+	}
+	for i := 1000; i < 2000; i++ {
+		got, ok := m.Lookup(uint64(i))
+		assertEqual(t, false, ok)
+		assertEqual(t, 0, got)
+	}
+
+	m.Delete(0)
+	got, ok := m.Lookup(0)
+	assertEqual(t, false, ok)
+	assertEqual(t, 0, got)
+
+	m.Set(0, 5)
+	got, ok = m.Lookup(0)
+	assertEqual(t, true, ok)
+	assertEqual(t, 5, got)
+}
+
+func TestPhiMap_SetNoAlloc(t *testing.T) {
+	m := NewPhiMap[uint64]()
+	for i := uint64(0); i < 1000; i++ {
+		m.Set(i, i)
+	}
+	var k uint64
+	allocs := testing.AllocsPerRun(1000, func() {
+		m.Set(k%1000, k+1000)
+		_ = m.Get(k % 1000)
+		k++
+	})
+	assertEqual(t, float64(0), allocs)
+}
+
+func TestPhiMap_Capacity(t *testing.T) {
+	const n = 10000
+
+	m := NewPhiMapWithCapacity[int](n)
+	capacity := len(m.data)
+	for i := 0; i < n; i++ {
+		m.Set(uint64(i), i)
+	}
+	assertEqual(t, capacity, len(m.data))
+	assertEqual(t, n, m.Size())
+
+	m.Reserve(n)
+	capacity = len(m.data)
+	for i := n; i < 2*n; i++ {
+		m.Set(uint64(i), i)
+	}
+	assertEqual(t, capacity, len(m.data))
+	assertEqual(t, 2*n, m.Size())
+	for i := 0; i < 2*n; i++ {
+		assertEqual(t, i, m.Get(uint64(i)))
+	}
+
+	// reserving less than the free space does nothing
+	m.Reserve(1)
+	assertEqual(t, capacity, len(m.data))
+}
+
+func TestPhiMap_FillFactor(t *testing.T) {
+	for _, f := range []float64{0.1, 0.3, 0.6, 0.8, 0.95} {
+		m := NewPhiMap[int](WithFillFactor(f), WithInitialCapacity(100))
+		assertEqual(t, f, m.fillFactor)
+		assertEqual(t, true, m.threshold >= 100)
+		assertEqual(t, calcThreshold(len(m.data), f), m.threshold)
+
+		for i := 1; i <= 5000; i++ {
+			m.Set(uint64(i), i)
+			if m.size > m.threshold || m.threshold >= len(m.data) {
+				t.Fatalf("fill factor %v: invalid state, size= %d, threshold= %d, capacity= %d",
+					f, m.size, m.threshold, len(m.data))
+			}
+		}
+		assertEqual(t, calcThreshold(len(m.data), f), m.threshold)
+		for i := 1; i <= 5000; i++ {
+			assertEqual(t, i, m.Get(uint64(i)))
+		}
+
+		m2 := m.Copy()
+		assertEqual(t, f, m2.fillFactor)
+		for i := 5001; i <= 10000; i++ {
+			m2.Set(uint64(i), i)
+		}
+		assertEqual(t, calcThreshold(len(m2.data), f), m2.threshold)
+	}
+
+	for _, f := range []float64{0, 0.05, 0.96, 1, -1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic for fill factor %v", f)
+				}
+			}()
+			WithFillFactor(f)
+		}()
+	}
+}
+
+func TestPhiMap_Compact(t *testing.T) {
+	m := NewPhiMap[int]()
+	for i := 0; i < 100000; i++ {
+		m.Set(uint64(i), i)
+	}
+	for i := 100; i < 100000; i++ {
+		m.Delete(uint64(i))
+	}
+	capacity := len(m.data)
+
+	m.Compact()
+	assertEqual(t, arraySize(100, m.fillFactor), len(m.data))
+	assertEqual(t, true, len(m.data) < capacity)
+	assertEqual(t, calcThreshold(len(m.data), m.fillFactor), m.threshold)
+	assertEqual(t, uint64(len(m.data)-1), m.mask)
+	assertEqual(t, 100, m.Size())
+	for i := 0; i < 100000; i++ {
+		got, ok := m.Lookup(uint64(i))
+		assertEqual(t, i < 100, ok)
+		if ok {
+			assertEqual(t, i, got)
+		}
+	}
+
+	// compacting again does nothing
+	capacity = len(m.data)
+	m.Compact()
+	assertEqual(t, capacity, len(m.data))
+}
+
+func TestPhiMap_AutoShrink(t *testing.T) {
+	m := NewPhiMap[int](WithAutoShrink(0.1))
+	for i := 1; i <= 100000; i++ {
+		m.Set(uint64(i), i)
+	}
+	maxCapacity := len(m.data)
+
+	for i := 1; i <= 100000; i++ {
+		m.Delete(uint64(i))
+		if m.size < m.shrinkThreshold {
+			t.Fatalf("map is not shrunk, size= %d, shrinkThreshold= %d", m.size, m.shrinkThreshold)
+		}
+		if i%1000 == 0 {
+			for j := i + 1; j <= i+1000 && j <= 100000; j++ {
+				assertEqual(t, j, m.Get(uint64(j)))
+			}
+		}
+	}
+	assertEqual(t, 0, m.Size())
+	assertEqual(t, arraySize(initSize, m.fillFactor), len(m.data))
+	assertEqual(t, true, len(m.data) < maxCapacity)
+
+	// hysteresis: adding and deleting around the boundary doesn't
+	// resize the map repeatedly
+	for i := 1; i <= 10000; i++ {
+		m.Set(uint64(i), i)
+	}
+	resizes := 0
+	dptr := m.dptr
+	for round := 0; round < 100; round++ {
+		for i := 1; i <= 10000; i++ {
+			if i%2 == 0 {
+				m.Delete(uint64(i))
+			}
+		}
+		for i := 1; i <= 10000; i++ {
+			if i%2 == 0 {
+				m.Set(uint64(i), i)
+			}
+		}
+		if m.dptr != dptr {
+			resizes++
+			dptr = m.dptr
+		}
+	}
+	assertEqual(t, 0, resizes)
+
+	for _, f := range []float64{0, -0.1, 0.3} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic for shrink factor %v", f)
+				}
+			}()
+			WithAutoShrink(f)
+		}()
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expected panic for shrink factor greater than fill factor / 4")
+			}
+		}()
+		NewPhiMap[int](WithFillFactor(0.2), WithAutoShrink(0.1))
+	}()
+}
+
+func TestPhiMap_Clear(t *testing.T) {
+	m := NewPhiMap[*AStruct]()
+	for i := 0; i < 1000; i++ {
+		m.Set(uint64(i), &AStruct{E: i})
+	}
+	capacity := len(m.data)
+	dptr := m.dptr
+
+	m.Clear()
+	assertEqual(t, 0, m.Size())
+	assertEqual(t, capacity, len(m.data))
+	assertEqual(t, dptr, m.dptr)
+	assertEqual(t, false, m.Has(0))
+	for _, e := range m.data {
+		assertEqual(t, uint64(FREE_KEY), e.K)
+		assertEqual(t, (*AStruct)(nil), e.V)
+	}
+	for i := 0; i < 1000; i++ {
+		assertEqual(t, (*AStruct)(nil), m.Get(uint64(i)))
+	}
+
+	// the cleared map is reusable without reallocating
+	for i := 0; i < 1000; i++ {
+		m.Set(uint64(i), &AStruct{E: i * 2})
+	}
+	assertEqual(t, 1000, m.Size())
+	assertEqual(t, dptr, m.dptr)
+	for i := 0; i < 1000; i++ {
+		assertEqual(t, i*2, m.Get(uint64(i)).E)
+	}
+}
+
+func TestPhiMap_Reset(t *testing.T) {
+	m := NewPhiMap[int]()
+	for i := 0; i < 1000; i++ {
+		m.Set(uint64(i), i)
+	}
+
+	m.Reset(10)
+	assertEqual(t, 0, m.Size())
+	assertEqual(t, arraySize(10, m.fillFactor), len(m.data))
+	assertEqual(t, calcThreshold(len(m.data), m.fillFactor), m.threshold)
+	assertEqual(t, uint64(len(m.data)-1), m.mask)
+	assertEqual(t, false, m.Has(0))
+	assertEqual(t, 0, len(m.Keys()))
+
+	m.Reset(100000)
+	capacity := len(m.data)
+	for i := 0; i < 100000; i++ {
+		m.Set(uint64(i), i)
+	}
+	assertEqual(t, capacity, len(m.data))
+	assertEqual(t, 100000, m.Size())
+}
+
+func assertPhiMapInvariants[T any](t *testing.T, m *PhiMap[T]) {
+	t.Helper()
+	capacity := len(m.data)
+	assertEqual(t, true, capacity&(capacity-1) == 0)
+	assertEqual(t, uint64(capacity-1), m.mask)
+	assertEqual(t, calcThreshold(capacity, m.fillFactor), m.threshold)
+	assertEqual(t, true, m.size <= m.threshold+1)
+	n := 0
+	for _, e := range m.data {
+		if e.K != FREE_KEY {
+			n++
+		}
+	}
+	if m.hasZero {
+		n++
+	}
+	assertEqual(t, n, m.size)
+}
+
+func TestPhiMap_Copy(t *testing.T) {
+	m := NewPhiMap[int](WithInitialCapacity(100))
+	threshold := m.threshold
+	for i := 0; i < threshold; i++ {
+		m.Set(uint64(i), i)
+	}
+	assertEqual(t, m.threshold, m.size)
+	assertPhiMapInvariants(t, m)
+
+	// size reaches the threshold, capacity is doubled
+	m2 := m.Copy()
+	assertEqual(t, len(m.data)*2, len(m2.data))
+	assertPhiMapInvariants(t, m2)
+	m2.Set(uint64(threshold), threshold)
+	assertEqual(t, len(m.data)*2, len(m2.data))
+	assertPhiMapInvariants(t, m2)
+
+	// same capacity
+	m.Delete(0)
+	m3 := m.Copy()
+	assertEqual(t, len(m.data), len(m3.data))
+	assertPhiMapInvariants(t, m3)
+	for i := 1; i < threshold; i++ {
+		assertEqual(t, i, m3.Get(uint64(i)))
+	}
+
+	m4 := m.CopyWithCapacity(10000)
+	capacity := len(m4.data)
+	assertPhiMapInvariants(t, m4)
+	for i := 10000; i < 20000; i++ {
+		m4.Set(uint64(i), i)
+	}
+	assertEqual(t, capacity, len(m4.data))
+	assertPhiMapInvariants(t, m4)
+	for i := 1; i < threshold; i++ {
+		assertEqual(t, i, m4.Get(uint64(i)))
+	}
+
+	// the copies are independent
+	m5 := m4.CopyWithCapacity(0)
+	assertEqual(t, len(m4.data), len(m5.data))
+	m5.Set(1, 100)
+	m5.Set(0, 100)
+	assertEqual(t, 1, m4.Get(1))
+	assertEqual(t, false, m4.Has(0))
+	assertPhiMapInvariants(t, m4)
+	assertPhiMapInvariants(t, m5)
+}
+
 type AStruct struct {
 	A int64
 	B string
@@ -134,6 +528,120 @@ type AStruct struct {
 	F [32]int64
 }
 
+func TestPhiMap_Update(t *testing.T) {
+	t.Run("PhiMap", func(t *testing.T) {
+		testPhiMapUpdate(t, NewPhiMap[int](WithAutoShrink(0.1)))
+	})
+	t.Run("HashMap", func(t *testing.T) {
+		testPhiMapUpdate(t, &NewHashMap[int](WithStrongHash(), WithAutoShrink(0.1)).m)
+	})
+}
+
+func testPhiMapUpdate(t *testing.T, m *PhiMap[int]) {
+	ref := make(map[uint64]int)
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 100000; i++ {
+		k := clusteredKey[uint64](rnd, 5000)
+		want, wantOk := ref[k]
+		switch rnd.Intn(4) {
+		case 0:
+			actual, loaded := m.GetOrSet(k, i)
+			assertEqual(t, wantOk, loaded)
+			if !loaded {
+				want = i
+				ref[k] = i
+			}
+			assertEqual(t, want, actual)
+		case 1:
+			called := false
+			got := m.GetOrCompute(k, func() int {
+				called = true
+				return i
+			})
+			assertEqual(t, !wantOk, called)
+			if called {
+				want = i
+				ref[k] = i
+			}
+			assertEqual(t, want, got)
+		case 2:
+			// counting, deletes the key when it reaches 3
+			m.Update(k, func(old int, ok bool) (int, bool) {
+				assertEqual(t, wantOk, ok)
+				assertEqual(t, want, old)
+				if want%4 == 3 {
+					return 0, false
+				}
+				return old + 1, true
+			})
+			if want%4 == 3 {
+				delete(ref, k)
+			} else {
+				ref[k] = want + 1
+			}
+		case 3:
+			old, ok := m.Swap(k, i)
+			assertEqual(t, wantOk, ok)
+			assertEqual(t, want, old)
+			ref[k] = i
+		}
+	}
+	assertPhiMapInvariants(t, m)
+	assertMapEqual(t, slowPhiMap[int]{m}, ref, 5000)
+
+	// Deleting by Update triggers auto shrinking.
+	for k := range ref {
+		m.Update(k, func(int, bool) (int, bool) { return 0, false })
+	}
+	assertEqual(t, 0, m.Size())
+	assertEqual(t, arraySize(initSize, fillFactor), len(m.data))
+}
+
+func TestPhiMap_DeleteFunc(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		newMap func() *PhiMap[int]
+	}{
+		{"PhiMap", func() *PhiMap[int] { return NewPhiMap[int]() }},
+		{"high fill factor", func() *PhiMap[int] { return NewPhiMap[int](WithFillFactor(0.95)) }},
+		{"auto shrink", func() *PhiMap[int] { return NewPhiMap[int](WithAutoShrink(0.1)) }},
+		{"HashMap", func() *PhiMap[int] { return &NewHashMap[int](WithStrongHash()).m }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rnd := rand.New(rand.NewSource(1))
+			for round := 0; round < 50; round++ {
+				m := tc.newMap()
+				ref := make(map[uint64]int)
+				n := rnd.Intn(5000)
+				for i := 0; i < n; i++ {
+					k := clusteredKey[uint64](rnd, n+1)
+					m.Set(k, i)
+					ref[k] = i
+				}
+				mod := rnd.Intn(5) + 1
+				pred := func(k uint64, v int) bool { return (k/64+uint64(v))%uint64(mod) == 0 }
+
+				want := 0
+				for k, v := range ref {
+					if pred(k, v) {
+						delete(ref, k)
+						want++
+					}
+				}
+				var deleted int
+				if round%2 == 0 {
+					deleted = m.DeleteFunc(pred)
+				} else {
+					deleted = m.Retain(func(k uint64, v int) bool { return !pred(k, v) })
+				}
+				assertEqual(t, want, deleted)
+				assertPhiMapInvariants(t, m)
+				assertMapEqual(t, slowPhiMap[int]{m}, ref, n+1)
+			}
+		})
+	}
+}
+
 func TestPhiMap_Types(t *testing.T) {
 	testData := make([]*AStruct, initSize)
 	for i := 0; i < initSize; i++ {
@@ -208,3 +716,66 @@ func assertEqual[T comparable](t *testing.T, left, right T) {
 		t.Errorf("values not equal, left= %v, right= %v", left, right)
 	}
 }
+
+// testMap is implemented by the maps which are exercised by testMapOps
+// and assertMapEqual.
+type testMap[K, T Integer] interface {
+	Size() int
+	Set(key K, val T)
+	Delete(key K)
+	Lookup(key K) (T, bool)
+	Has(key K) bool
+}
+
+// slowPhiMap adapts a PhiMap to testMap, its Lookup and Has take the
+// slow path, which uses the hash function of a HashMap.
+type slowPhiMap[T Integer] struct {
+	*PhiMap[T]
+}
+
+func (m slowPhiMap[T]) Lookup(key uint64) (T, bool) {
+	return m.tab().lookup(key)
+}
+
+func (m slowPhiMap[T]) Has(key uint64) bool {
+	_, ok := m.tab().lookup(key)
+	return ok
+}
+
+// clusteredKey returns one of n clustered keys, which are the multiples
+// of 64 below n*64, including key 0.
+func clusteredKey[K Integer](rnd *rand.Rand, n int) K {
+	return K(rnd.Intn(n)) * 64
+}
+
+// testMapOps does ops random Set and Delete of n clustered keys to both
+// m and ref, two thirds of them are Set.
+func testMapOps[K, T Integer](m testMap[K, T], ref map[K]T, rnd *rand.Rand, ops, n int) {
+	for i := 0; i < ops; i++ {
+		k := clusteredKey[K](rnd, n)
+		switch rnd.Intn(3) {
+		case 0, 1:
+			m.Set(k, T(i))
+			ref[k] = T(i)
+		case 2:
+			m.Delete(k)
+			delete(ref, k)
+		}
+	}
+}
+
+// assertMapEqual asserts that m has exactly the entries in ref,
+// whose keys are among n clustered keys.
+func assertMapEqual[K, T Integer](t *testing.T, m testMap[K, T], ref map[K]T, n int) {
+	t.Helper()
+	assertEqual(t, len(ref), m.Size())
+	for i := 0; i < n; i++ {
+		key := K(i) * 64
+		want, wantOk := ref[key]
+		got, ok := m.Lookup(key)
+		assertEqual(t, wantOk, ok)
+		assertEqual(t, want, got)
+		assertEqual(t, wantOk, m.Has(key))
+		assertEqual(t, false, m.Has(key+1))
+	}
+}
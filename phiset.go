@@ -0,0 +1,181 @@
+package phimap
+
+import "iter"
+
+// PhiSet is a set of uint64 keys, it is a SplitMap[struct{}], which
+// stores only keys, a slot takes 8 bytes.
+// Note that a PhiMap[struct{}] slot takes more than the key, the empty
+// value is padded after the key.
+type PhiSet struct {
+	m SplitMap[struct{}]
+}
+
+// NewPhiSet creates a new PhiSet.
+func NewPhiSet(opts ...Option) *PhiSet {
+	return &PhiSet{m: *NewSplitMap[struct{}](opts...)}
+}
+
+// Len returns the number of keys in the set.
+func (s *PhiSet) Len() int {
+	return s.m.Size()
+}
+
+// Has tells whether a key exists in the set.
+// It is optimized to be inline-able.
+func (s *PhiSet) Has(key uint64) bool {
+	return s.m.Has(key)
+}
+
+// Add adds key to the set, it returns false if the key already exists.
+func (s *PhiSet) Add(key uint64) bool {
+	t := s.m.tab()
+	if key == FREE_KEY {
+		if t.hasZero {
+			return false
+		}
+		t.setZero(struct{}{}, true)
+		return true
+	}
+	ptr, found := t.findSlot(key)
+	if found {
+		return false
+	}
+	t.insertAt(ptr, key, struct{}{})
+	return true
+}
+
+// Remove deletes key from the set, it returns false if the key does
+// not exist.
+func (s *PhiSet) Remove(key uint64) bool {
+	t := s.m.tab()
+	if key == FREE_KEY {
+		if !t.hasZero {
+			return false
+		}
+		t.delete(key)
+		return true
+	}
+	ptr, found := t.findSlot(key)
+	if !found {
+		return false
+	}
+	t.deleteAt(ptr)
+	return true
+}
+
+// Reserve grows the set if needed, to make sure that another n keys
+// can be added to the set without growing again.
+func (s *PhiSet) Reserve(n int) {
+	s.m.Reserve(n)
+}
+
+// Clear deletes all keys from the set, it keeps the allocated
+// capacity to be reused.
+func (s *PhiSet) Clear() {
+	s.m.Clear()
+}
+
+// Compact shrinks the set to the smallest capacity which can hold
+// the keys in the set.
+// It helps to release memory after deleting lots of keys.
+func (s *PhiSet) Compact() {
+	s.m.Compact()
+}
+
+// Clone returns a copy of the set.
+func (s *PhiSet) Clone() *PhiSet {
+	return &PhiSet{m: *s.m.CopyWithCapacity(0)}
+}
+
+// Keys returns all keys in the set, in no particular order.
+func (s *PhiSet) Keys() []uint64 {
+	return s.m.Keys()
+}
+
+// All returns an iterator over keys in the set, in no particular order.
+// See PhiMap.All for the behavior when the set is modified during iteration.
+func (s *PhiSet) All() iter.Seq[uint64] {
+	return s.m.KeysSeq()
+}
+
+// newSet returns an empty set with the same options as s,
+// which can hold n keys without growing.
+func (s *PhiSet) newSet(n int) *PhiSet {
+	newSet := &PhiSet{m: SplitMap[struct{}]{split: true}}
+	newSet.m.tab().init(&options{
+		fillFactor:   s.m.fillFactor,
+		capacity:     n,
+		shrinkFactor: s.m.shrinkFactor,
+	})
+	return newSet
+}
+
+// Union returns a new set of keys in either s or other.
+// It copies the larger set and adds keys of the smaller one,
+// the result has the options of the larger set.
+func (s *PhiSet) Union(other *PhiSet) *PhiSet {
+	small, large := s, other
+	if small.Len() > large.Len() {
+		small, large = large, small
+	}
+	result := &PhiSet{m: *large.m.CopyWithCapacity(small.Len())}
+	for key := range small.All() {
+		result.Add(key)
+	}
+	return result
+}
+
+// Intersect returns a new set of keys in both s and other.
+// It iterates over the smaller set, the result has the options of s.
+func (s *PhiSet) Intersect(other *PhiSet) *PhiSet {
+	small, large := s, other
+	if small.Len() > large.Len() {
+		small, large = large, small
+	}
+	result := s.newSet(small.Len())
+	for key := range small.All() {
+		if large.Has(key) {
+			result.Add(key)
+		}
+	}
+	return result
+}
+
+// Difference returns a new set of keys in s but not in other.
+// It iterates over the smaller set, if other is the smaller one,
+// s is copied and keys of other are removed from the copy.
+// The result has the options of s.
+func (s *PhiSet) Difference(other *PhiSet) *PhiSet {
+	if s.Len() <= other.Len() {
+		result := s.newSet(s.Len())
+		for key := range s.All() {
+			if !other.Has(key) {
+				result.Add(key)
+			}
+		}
+		return result
+	}
+	result := s.Clone()
+	for key := range other.All() {
+		result.Remove(key)
+	}
+	return result
+}
+
+// IsSubset tells whether all keys in s are also in other.
+func (s *PhiSet) IsSubset(other *PhiSet) bool {
+	if s.Len() > other.Len() {
+		return false
+	}
+	for key := range s.All() {
+		if !other.Has(key) {
+			return false
+		}
+	}
+	return true
+}
+
+// Equal tells whether s and other contain the same keys.
+func (s *PhiSet) Equal(other *PhiSet) bool {
+	return s.Len() == other.Len() && s.IsSubset(other)
+}
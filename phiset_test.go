@@ -0,0 +1,105 @@
+package phimap
+
+import (
+	"math/rand"
+	"testing"
+	"unsafe"
+)
+
+func TestPhiSet(t *testing.T) {
+	s := NewPhiSet(WithAutoShrink(0.1))
+	ref := make(map[uint64]bool)
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 100000; i++ {
+		k := uint64(rnd.Intn(5000)) * 64 // including key 0
+		switch rnd.Intn(3) {
+		case 0, 1:
+			assertEqual(t, !ref[k], s.Add(k))
+			ref[k] = true
+		case 2:
+			assertEqual(t, ref[k], s.Remove(k))
+			delete(ref, k)
+		}
+		assertEqual(t, len(ref), s.Len())
+	}
+	for k := uint64(0); k < 5000*64; k += 32 {
+		assertEqual(t, ref[k], s.Has(k))
+	}
+	n := 0
+	for k := range s.All() {
+		assertEqual(t, true, ref[k])
+		n++
+	}
+	assertEqual(t, len(ref), n)
+	assertEqual(t, len(ref), len(s.Keys()))
+
+	c := s.Clone()
+	assertEqual(t, true, c.Equal(s))
+	c.Add(1)
+	assertEqual(t, false, c.Equal(s))
+	assertEqual(t, false, s.Has(1))
+
+	s.Clear()
+	assertEqual(t, 0, s.Len())
+	assertEqual(t, false, s.Has(0))
+
+	// PhiMap[struct{}] pads the empty value after the key,
+	// while a PhiSet slot takes only the key.
+	assertEqual(t, uintptr(8), s.m.kstride+s.m.vstride)
+	assertEqual(t, true, s.m.kstride+s.m.vstride < unsafe.Sizeof(entry[uint64, struct{}]{}))
+}
+
+func TestPhiSet_Algebra(t *testing.T) {
+	newSet := func(keys ...uint64) *PhiSet {
+		s := NewPhiSet()
+		for _, k := range keys {
+			s.Add(k)
+		}
+		return s
+	}
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		a, b := NewPhiSet(), NewPhiSet()
+		for j := rnd.Intn(1000); j > 0; j-- {
+			a.Add(uint64(rnd.Intn(1000)))
+		}
+		for j := rnd.Intn(1000); j > 0; j-- {
+			b.Add(uint64(rnd.Intn(1000)))
+		}
+
+		union, inter := newSet(), newSet()
+		diffAB, diffBA := newSet(), newSet()
+		for k := uint64(0); k < 1000; k++ {
+			inA, inB := a.Has(k), b.Has(k)
+			if inA || inB {
+				union.Add(k)
+			}
+			if inA && inB {
+				inter.Add(k)
+			}
+			if inA && !inB {
+				diffAB.Add(k)
+			}
+			if inB && !inA {
+				diffBA.Add(k)
+			}
+		}
+		assertEqual(t, true, a.Union(b).Equal(union))
+		assertEqual(t, true, b.Union(a).Equal(union))
+		assertEqual(t, true, a.Intersect(b).Equal(inter))
+		assertEqual(t, true, b.Intersect(a).Equal(inter))
+		assertEqual(t, true, a.Difference(b).Equal(diffAB))
+		assertEqual(t, true, b.Difference(a).Equal(diffBA))
+		assertEqual(t, diffAB.Len() == 0, a.IsSubset(b))
+		assertEqual(t, diffBA.Len() == 0, b.IsSubset(a))
+		assertEqual(t, true, inter.IsSubset(a) && a.IsSubset(union))
+	}
+
+	a := newSet(0, 1, 2)
+	assertEqual(t, true, a.Equal(newSet(2, 1, 0)))
+	assertEqual(t, false, a.Equal(newSet(1, 2, 3)))
+	assertEqual(t, true, newSet().IsSubset(a))
+	assertEqual(t, false, a.IsSubset(newSet(0, 1)))
+	assertEqual(t, 0, a.Difference(a).Len())
+	assertEqual(t, 3, a.Intersect(a.Union(newSet(3))).Len())
+}
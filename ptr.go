@@ -0,0 +1,69 @@
+package phimap
+
+// GetPtr returns a pointer to the value of key in the hash table,
+// which can be used to modify the value in place.
+// It returns nil if the key is not found.
+//
+// The pointer is only valid until the map is modified by Set, Delete,
+// or any method which may add, delete or move entries.
+// Rehashing moves all entries to a new hash table, and deleting shifts
+// entries backward in a probing cluster, thus an invalid pointer points
+// to a stale copy or even another key's value.
+//
+// Build with tag phimapdebug to detect writing through an invalid
+// pointer, the detection is best-effort and delayed:
+//   - Only writing into a hash table which is left by rehashing is
+//     detected. Writing through a pointer invalidated by Delete,
+//     DeleteFunc or Clear, which move or clear entries in place, is only
+//     detected if it happens after the map is rehashed.
+//   - It panics at the next call of GetPtr, SetPtr, or rehashing,
+//     not at the write.
+func (m *PhiMap[T]) GetPtr(key uint64) *T {
+	t := m.tab()
+	t.debug.check()
+	if key == FREE_KEY {
+		if !t.hasZero {
+			return nil
+		}
+		return &t.zeroVal
+	}
+	ptr, found := t.findSlot(key)
+	if !found {
+		return nil
+	}
+	v := t.getV(ptr)
+	t.debug.track(v)
+	return v
+}
+
+// SetPtr returns a pointer to the value of key in the hash table,
+// which can be used to modify the value in place.
+// If the key is not found, it adds the key with zero value of T,
+// and inserted is true.
+//
+// The pointer is only valid until the map is modified,
+// see GetPtr for details.
+func (m *PhiMap[T]) SetPtr(key uint64) (val *T, inserted bool) {
+	t := m.tab()
+	t.debug.check()
+	if key == FREE_KEY {
+		if !t.hasZero {
+			var zero T
+			t.setZero(zero, true)
+			inserted = true
+		}
+		return &t.zeroVal, inserted
+	}
+	ptr, found := t.findSlot(key)
+	if !found {
+		var zero T
+		t.insertAt(ptr, key, zero)
+		if *t.getK(ptr) != key { // rehashed
+			ptr, _ = t.findSlot(key)
+		}
+		inserted = true
+	}
+	v := t.getV(ptr)
+	t.debug.track(v)
+	return v, inserted
+}
@@ -0,0 +1,44 @@
+package phimap
+
+import "testing"
+
+func TestPhiMap_GetPtr(t *testing.T) {
+	type counter struct {
+		key   uint64
+		count int
+	}
+	m := NewPhiMap[counter]()
+	ref := make(map[uint64]int)
+	for i := 0; i < 100000; i++ {
+		k := uint64(i%3000) * 64 // including key 0
+		p, inserted := m.SetPtr(k)
+		_, ok := ref[k]
+		assertEqual(t, !ok, inserted)
+		if inserted {
+			assertEqual(t, counter{}, *p)
+			p.key = k
+		}
+		p.count++
+		ref[k]++
+	}
+	assertPhiMapInvariants(t, m)
+	assertEqual(t, len(ref), m.Size())
+	for k, n := range ref {
+		assertEqual(t, counter{key: k, count: n}, m.Get(k))
+		p := m.GetPtr(k)
+		p.count = 0
+		assertEqual(t, 0, m.Get(k).count)
+	}
+	assertEqual(t, true, m.GetPtr(1) == nil)
+	m.Delete(0)
+	assertEqual(t, true, m.GetPtr(0) == nil)
+
+	hm := NewHashMap[int](WithStrongHash())
+	for i := 0; i < 1000; i++ {
+		p, _ := hm.SetPtr(uint64(i))
+		*p = i
+	}
+	for i := 0; i < 1000; i++ {
+		assertEqual(t, i, *hm.GetPtr(uint64(i)))
+	}
+}
@@ -0,0 +1,94 @@
+package phimap
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func assertRobinHoodInvariants[T any](t *testing.T, m *RobinHoodMap[T]) {
+	t.Helper()
+	pm := m.m.tab()
+	n := 0
+	for i := range pm.data {
+		k := pm.data[i].K
+		if k == FREE_KEY {
+			continue
+		}
+		n++
+		ptr := uint64(i)
+		dist := pm.rhDist(k, ptr)
+		// All slots between the home slot and the entry are occupied,
+		// by entries which are not closer to their home slots.
+		for d := uint64(1); d <= dist; d++ {
+			p := (ptr - d) & pm.mask
+			pk := pm.data[p].K
+			if pk == FREE_KEY {
+				t.Fatalf("free slot between key %d and its home slot", k)
+			}
+			if pm.rhDist(pk, p) < dist-d {
+				t.Fatalf("key %d is not displaced by a farther key %d", pk, k)
+			}
+		}
+	}
+	if pm.hasZero {
+		n++
+	}
+	assertEqual(t, n, pm.size)
+}
+
+func TestRobinHoodMap(t *testing.T) {
+	for _, f := range []float64{0.6, 0.9, 0.95} {
+		m := NewRobinHoodMap[int](WithFillFactor(f))
+		ref := make(map[uint64]int)
+		rnd := rand.New(rand.NewSource(1))
+		for i := 0; i < 10; i++ {
+			testMapOps(m, ref, rnd, 20000, 20000)
+			assertRobinHoodInvariants(t, m)
+		}
+		assertMapEqual(t, m, ref, 20000)
+
+		m2 := m.CopyWithCapacity(100000)
+		assertRobinHoodInvariants(t, m2)
+		m.Compact()
+		assertRobinHoodInvariants(t, m)
+		for k, v := range ref {
+			assertEqual(t, v, m.Get(k))
+			assertEqual(t, v, m2.Get(k))
+		}
+
+		n := 0
+		for k := range m.All() {
+			m.Delete(k)
+			n++
+		}
+		assertEqual(t, len(ref), n)
+		assertEqual(t, 0, m.Size())
+	}
+}
+
+func TestRobinHoodMap_ProbeLength(t *testing.T) {
+	keys := make([]uint64, 50000)
+	for i := range keys {
+		keys[i] = uint64(i) * 64
+	}
+	pm := NewPhiMap[int](WithFillFactor(0.9))
+	rm := NewRobinHoodMap[int](WithFillFactor(0.9))
+	for i, k := range keys {
+		pm.Set(k, i)
+		rm.Set(k, i)
+	}
+	maxProbe := func(m *PhiMap[int]) uint64 {
+		var result uint64
+		for i, e := range m.data {
+			if e.K != FREE_KEY {
+				result = max(result, (uint64(i)-phiMix(e.K))&m.mask)
+			}
+		}
+		return result
+	}
+	pmMax, rmMax := maxProbe(pm), maxProbe(&rm.m)
+	t.Logf("max probe length: PhiMap= %d, RobinHoodMap= %d", pmMax, rmMax)
+	if rmMax > pmMax {
+		t.Errorf("expected shorter max probe length with Robin Hood hashing")
+	}
+}
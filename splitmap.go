@@ -0,0 +1,181 @@
+package phimap
+
+import (
+	"iter"
+	"unsafe"
+)
+
+// SplitMap is a variant of PhiMap which uses a struct-of-arrays layout,
+// keys are stored in a dense []uint64 and values in a parallel []T.
+//
+// Probing only touches the key array, a cache line holds 8 keys,
+// thus Has and Get of missing keys are cheaper, especially with large
+// values. While a hit touches another cache line for the value,
+// it gains little for hit-heavy workloads.
+// See Benchmark_SplitMap for a comparison.
+type SplitMap[T any] table[uint64, T]
+
+// NewSplitMap creates a new SplitMap.
+func NewSplitMap[T any](opts ...Option) *SplitMap[T] {
+	m := &SplitMap[T]{split: true}
+	m.tab().init(newMapOptions(opts))
+	return m
+}
+
+// tab returns the hash table of the map.
+func (m *SplitMap[T]) tab() *table[uint64, T] {
+	return (*table[uint64, T])(m)
+}
+
+// Size returns the size of the map.
+func (m *SplitMap[T]) Size() int {
+	return m.size
+}
+
+// Get returns the value if the key is found, else it returns zero value of T.
+// It is optimized to be inline-able.
+func (m *SplitMap[T]) Get(key uint64) (value T) {
+	// manually inline phiMix to help inlining
+	h := key * INT_PHI
+	ptr := h ^ (h >> 16)
+
+	for {
+		ptr &= m.mask
+		// manually inline m.getK and m.getV
+		k := *(*uint64)(unsafe.Pointer(uintptr(m.dptr) + uintptr(ptr)*u64Size))
+		if k == key {
+			if key == FREE_KEY {
+				return m.zeroVal
+			}
+			return *(*T)(unsafe.Pointer(uintptr(m.vptr) + uintptr(ptr)*unsafe.Sizeof(value)))
+		}
+		if k == FREE_KEY {
+			return
+		}
+		ptr += 1
+	}
+}
+
+// Lookup returns the value and true if the key is found,
+// else it returns zero value of T and false.
+// It is optimized to be inline-able.
+func (m *SplitMap[T]) Lookup(key uint64) (value T, ok bool) {
+	// manually inline phiMix to help inlining
+	h := key * INT_PHI
+	ptr := h ^ (h >> 16)
+
+	for {
+		ptr &= m.mask
+		// manually inline m.getK and m.getV
+		k := *(*uint64)(unsafe.Pointer(uintptr(m.dptr) + uintptr(ptr)*u64Size))
+		if k == key {
+			if key == FREE_KEY {
+				return m.zeroVal, m.hasZero
+			}
+			return *(*T)(unsafe.Pointer(uintptr(m.vptr) + uintptr(ptr)*unsafe.Sizeof(value))), true
+		}
+		if k == FREE_KEY {
+			return
+		}
+		ptr += 1
+	}
+}
+
+// Has tells whether a key exists in the map.
+// It only touches the key array, and is optimized to be inline-able.
+func (m *SplitMap[T]) Has(key uint64) bool {
+	// manually inline phiMix to help inlining
+	h := key * INT_PHI
+	ptr := h ^ (h >> 16)
+
+	for {
+		ptr &= m.mask
+		// manually inline m.getK
+		k := *(*uint64)(unsafe.Pointer(uintptr(m.dptr) + uintptr(ptr)*u64Size))
+		if k == key {
+			return key != FREE_KEY || m.hasZero
+		}
+		if k == FREE_KEY {
+			return false
+		}
+		ptr += 1
+	}
+}
+
+// Set adds or updates key with value to the map.
+func (m *SplitMap[T]) Set(key uint64, val T) {
+	m.tab().set(key, val)
+}
+
+// Reserve grows the map if needed, to make sure that another n entries
+// can be added to the map without growing again.
+func (m *SplitMap[T]) Reserve(n int) {
+	m.tab().reserve(n)
+}
+
+// Clear deletes all entries from the map, it keeps the allocated
+// capacity to be reused.
+// Keys and values are zeroed, thus the values can be garbage collected.
+func (m *SplitMap[T]) Clear() {
+	m.tab().clear()
+}
+
+// Reset deletes all entries from the map, and reallocates the map
+// to be able to hold capacity entries without growing.
+func (m *SplitMap[T]) Reset(capacity int) {
+	m.tab().reset(capacity)
+}
+
+// Compact shrinks the map to the smallest capacity which can hold
+// the entries in the map.
+// It helps to release memory after deleting lots of entries.
+func (m *SplitMap[T]) Compact() {
+	m.tab().compact()
+}
+
+// Delete deletes an element from the map.
+func (m *SplitMap[T]) Delete(key uint64) {
+	m.tab().delete(key)
+}
+
+// Copy returns a copy of a SplitMap, if the map's size reaches the
+// threshold, the new map's capacity will be twice of the old.
+func (m *SplitMap[T]) Copy() *SplitMap[T] {
+	return (*SplitMap[T])(m.tab().clone())
+}
+
+// CopyWithCapacity returns a copy of a SplitMap, which can hold another
+// n entries without growing.
+func (m *SplitMap[T]) CopyWithCapacity(n int) *SplitMap[T] {
+	return (*SplitMap[T])(m.tab().cloneWithCapacity(n))
+}
+
+// Keys returns all keys in the map, in no particular order.
+func (m *SplitMap[T]) Keys() []uint64 {
+	return m.tab().keys()
+}
+
+// Items returns all key value entries in the map, in no particular order.
+//
+// Values are boxed into Entry.V as interface for compatibility with
+// PhiMap.Items, which allocates for most non-pointer types.
+func (m *SplitMap[T]) Items() []Entry {
+	return m.tab().items()
+}
+
+// All returns an iterator over key value pairs in the map,
+// in no particular order.
+// See PhiMap.All for the behavior when the map is modified during iteration.
+func (m *SplitMap[T]) All() iter.Seq2[uint64, T] {
+	return m.tab().iterate
+}
+
+// KeysSeq returns an iterator over keys in the map, in no particular order.
+func (m *SplitMap[T]) KeysSeq() iter.Seq[uint64] {
+	return m.tab().keysSeq()
+}
+
+// Values returns an iterator over values in the map, in no particular order.
+func (m *SplitMap[T]) Values() iter.Seq[T] {
+	return m.tab().values()
+}
@@ -0,0 +1,44 @@
+package phimap
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSplitMap(t *testing.T) {
+	m := NewSplitMap[int](WithAutoShrink(0.1))
+	ref := make(map[uint64]int)
+	testMapOps(m, ref, rand.New(rand.NewSource(1)), 200000, 20000)
+	assertMapEqual(t, m, ref, 20000)
+
+	m2 := m.CopyWithCapacity(100000)
+	m3 := m.Copy()
+	m.Compact()
+	for k, v := range ref {
+		assertEqual(t, v, m.Get(k))
+		assertEqual(t, v, m2.Get(k))
+		assertEqual(t, v, m3.Get(k))
+	}
+	assertEqual(t, len(ref), len(m.Keys()))
+	for _, e := range m.Items() {
+		assertEqual(t, ref[e.K], e.V.(int))
+	}
+
+	// Deleting all entries during iteration shrinks the map.
+	n := 0
+	for k, v := range m.All() {
+		assertEqual(t, ref[k], v)
+		m.Delete(k)
+		n++
+	}
+	assertEqual(t, len(ref), n)
+	assertEqual(t, 0, m.Size())
+	assertEqual(t, arraySize(initSize, fillFactor), len(m.skeys))
+
+	m2.Clear()
+	assertEqual(t, 0, m2.Size())
+	assertEqual(t, 0, len(m2.Keys()))
+	m3.Reset(1000)
+	assertEqual(t, 0, m3.Size())
+	assertEqual(t, arraySize(1000, fillFactor), len(m3.skeys))
+}
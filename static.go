@@ -0,0 +1,244 @@
+package phimap
+
+import (
+	"cmp"
+	"iter"
+	"math"
+	"math/bits"
+	"slices"
+)
+
+const (
+	// staticLoad is the load factor of a StaticMap's hash table.
+	staticLoad = 0.85
+
+	// staticBucketSize is the average number of keys in a bucket.
+	staticBucketSize = 4
+
+	// staticMaxSeed is the number of seeds tried to place a bucket,
+	// before building again with a larger table.
+	staticMaxSeed = 1 << 16
+)
+
+// StaticMap is an immutable map built by BuildStatic, for tables which
+// are built once and read forever, e.g. protocol field numbers to handlers.
+//
+// It is a perfect hash table using CHD (compress, hash and displace):
+// keys are hashed into buckets, each bucket has a displacement seed
+// which places its keys into distinct slots, thus every key has exactly
+// one slot to check. Get loads a bucket's seed and a slot, it never
+// probes further.
+//
+// The table is filled to 85%, plus 2 bytes of seed per bucket of 4 keys
+// on average, it is smaller than a PhiMap at fill factor 0.6, which takes
+// at least 1.67 slots per entry. The bounded lookup is not faster than
+// PhiMap.Get on average, which is inlined and usually finds the key in
+// the first slot, StaticMap trades some speed for the footprint and the
+// worst case.
+//
+// A StaticMap is safe to use concurrently.
+type StaticMap[T any] struct {
+	data  []entry[uint64, T]
+	seeds []uint16
+	seed  uint64
+	size  int
+
+	hasZero bool
+	zeroVal T
+}
+
+// BuildStatic builds a StaticMap holding the given entries,
+// e.g. PhiMap.All or maps.All. If a key occurs more than once,
+// the last value is kept.
+func BuildStatic[T any](entries iter.Seq2[uint64, T]) *StaticMap[T] {
+	m := &StaticMap[T]{}
+	index := NewPhiMap[int]()
+	var keys []uint64
+	var vals []T
+	for k, v := range entries {
+		if k == FREE_KEY {
+			m.hasZero = true
+			m.zeroVal = v
+			continue
+		}
+		if i, ok := index.Lookup(k); ok {
+			vals[i] = v
+			continue
+		}
+		index.Set(k, len(keys))
+		keys = append(keys, k)
+		vals = append(vals, v)
+	}
+	m.size = len(keys)
+	if m.hasZero {
+		m.size++
+	}
+
+	// Building fails rarely, try again with another seed
+	// and a larger table.
+	nslots := max(int(math.Ceil(float64(len(keys))/staticLoad)), 1)
+	seed := uint64(wyp0)
+	for {
+		seeds, slots, ok := placeStatic(keys, seed, nslots)
+		if ok {
+			m.data = make([]entry[uint64, T], nslots)
+			for i, s := range slots {
+				m.data[s] = entry[uint64, T]{K: keys[i], V: vals[i]}
+			}
+			m.seeds = seeds
+			m.seed = seed
+			return m
+		}
+		seed = wyMix(seed, wyp1)
+		nslots += nslots/16 + 1
+	}
+}
+
+// placeStatic searches a seed for each bucket of keys, which places
+// all keys in distinct slots of a table of nslots.
+// It returns the seeds and the slot of each key.
+func placeStatic(keys []uint64, seed uint64, nslots int) (seeds []uint16, slots []uint64, ok bool) {
+	nbuckets := max((len(keys)+staticBucketSize-1)/staticBucketSize, 1)
+
+	// Group keys by bucket with counting sort.
+	hashes := make([]uint64, len(keys))
+	start := make([]int, nbuckets+1)
+	for i, k := range keys {
+		hashes[i] = staticHash(k, seed)
+		start[reduceRange(hashes[i], nbuckets)+1]++
+	}
+	for b := 0; b < nbuckets; b++ {
+		start[b+1] += start[b]
+	}
+	members := make([]int, len(keys))
+	next := slices.Clone(start[:nbuckets])
+	for i, h := range hashes {
+		b := reduceRange(h, nbuckets)
+		members[next[b]] = i
+		next[b]++
+	}
+
+	// Place larger buckets first, while the table is still sparse.
+	order := make([]int, nbuckets)
+	for b := range order {
+		order[b] = b
+	}
+	bucketSize := func(b int) int { return start[b+1] - start[b] }
+	slices.SortFunc(order, func(a, b int) int {
+		return cmp.Compare(bucketSize(b), bucketSize(a))
+	})
+
+	seeds = make([]uint16, nbuckets)
+	slots = make([]uint64, len(keys))
+	taken := make([]bool, nslots)
+	var cur []uint64
+	for _, b := range order {
+		bucket := members[start[b]:start[b+1]]
+		if len(bucket) == 0 {
+			break
+		}
+		for d := 0; ; d++ {
+			if d == staticMaxSeed {
+				return nil, nil, false
+			}
+			cur = cur[:0]
+			for _, i := range bucket {
+				s := reduceRange(staticSlot(hashes[i], uint16(d)), nslots)
+				if taken[s] || slices.Contains(cur, s) {
+					break
+				}
+				cur = append(cur, s)
+			}
+			if len(cur) == len(bucket) {
+				seeds[b] = uint16(d)
+				for j, i := range bucket {
+					taken[cur[j]] = true
+					slots[i] = cur[j]
+				}
+				break
+			}
+		}
+	}
+	return seeds, slots, true
+}
+
+// staticHash hashes a key for bucketing, bucket index and slot index
+// are taken from the high bits, thus the high bits are mixed into
+// the low bits to feed staticSlot.
+func staticHash(key, seed uint64) uint64 {
+	h := (key ^ seed) * 0x9e3779b97f4a7c15
+	return h ^ (h >> 29)
+}
+
+func staticSlot(h uint64, d uint16) uint64 {
+	return (h ^ uint64(d)) * 0xbf58476d1ce4e5b9
+}
+
+// reduceRange maps h to [0, n) without division,
+// it uses the high bits of h.
+func reduceRange(h uint64, n int) uint64 {
+	hi, _ := bits.Mul64(h, uint64(n))
+	return hi
+}
+
+// Size returns the number of entries in the map.
+func (m *StaticMap[T]) Size() int {
+	return m.size
+}
+
+// Get returns the value if the key is found in the map,
+// else it returns zero value of T.
+func (m *StaticMap[T]) Get(key uint64) T {
+	val, _ := m.Lookup(key)
+	return val
+}
+
+// Lookup returns the value and true if the key is found in the map,
+// else it returns zero value of T and false.
+func (m *StaticMap[T]) Lookup(key uint64) (value T, ok bool) {
+	if key == FREE_KEY {
+		return m.zeroVal, m.hasZero
+	}
+	h := staticHash(key, m.seed)
+	d := m.seeds[reduceRange(h, len(m.seeds))]
+	e := &m.data[reduceRange(staticSlot(h, d), len(m.data))]
+	if e.K == key {
+		return e.V, true
+	}
+	return
+}
+
+// Has tells whether a key exists in the map.
+func (m *StaticMap[T]) Has(key uint64) bool {
+	_, ok := m.Lookup(key)
+	return ok
+}
+
+// Keys returns all keys in the map, in no particular order.
+func (m *StaticMap[T]) Keys() []uint64 {
+	keys := make([]uint64, 0, m.size)
+	for k := range m.All() {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// All returns an iterator over key value pairs in the map,
+// in no particular order.
+func (m *StaticMap[T]) All() iter.Seq2[uint64, T] {
+	return func(yield func(uint64, T) bool) {
+		if m.hasZero {
+			if !yield(FREE_KEY, m.zeroVal) {
+				return
+			}
+		}
+		for i := range m.data {
+			e := &m.data[i]
+			if e.K != FREE_KEY {
+				if !yield(e.K, e.V) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package phimap
+
+import (
+	"maps"
+	"slices"
+	"testing"
+	"unsafe"
+)
+
+func TestBuildStatic(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 3, 10, 1000, 100000} {
+		want := make(map[uint64]int, n)
+		for i := 0; i < n; i++ {
+			want[uint64(i)*64] = i // including key 0
+		}
+		m := BuildStatic(maps.All(want))
+		assertEqual(t, n, m.Size())
+		for k, v := range want {
+			got, ok := m.Lookup(k)
+			assertEqual(t, true, ok)
+			assertEqual(t, v, got)
+			// keys are multiples of 64, k+8 is absent
+			_, ok = m.Lookup(k + 8)
+			assertEqual(t, false, ok)
+			assertEqual(t, false, m.Has(k+8))
+		}
+		assertEqual(t, n, len(m.Keys()))
+		for k, v := range m.All() {
+			assertEqual(t, want[k], v)
+		}
+		assertEqual(t, 0, m.Get(1<<63))
+	}
+}
+
+func TestBuildStatic_Duplicates(t *testing.T) {
+	keys := []uint64{5, 0, 7, 5, 0, 5}
+	m := BuildStatic(func(yield func(uint64, int) bool) {
+		for i, k := range keys {
+			if !yield(k, i) {
+				return
+			}
+		}
+	})
+	assertEqual(t, 3, m.Size())
+	assertEqual(t, 5, m.Get(5))
+	assertEqual(t, 4, m.Get(0))
+	assertEqual(t, 2, m.Get(7))
+	assertEqual(t, true, slices.Equal([]uint64{0, 5, 7}, slices.Sorted(slices.Values(m.Keys()))))
+}
+
+func TestStaticMap_Footprint(t *testing.T) {
+	for _, n := range []int{100, 1000, 5000, 100000} {
+		pm := NewPhiMap[int](WithFillFactor(0.6))
+		for i := 1; i <= n; i++ {
+			pm.Set(uint64(i)*64, i)
+		}
+		sm := BuildStatic(pm.All())
+		assertEqual(t, n, sm.Size())
+
+		pmBytes := len(pm.data) * int(unsafe.Sizeof(entry[uint64, int]{}))
+		smBytes := len(sm.data)*int(unsafe.Sizeof(entry[uint64, int]{})) + len(sm.seeds)*2
+		assertEqual(t, true, smBytes < pmBytes)
+	}
+}
@@ -0,0 +1,356 @@
+package phimap
+
+import (
+	"iter"
+	"math/bits"
+)
+
+// SwissMap control bytes, a full slot has the 7 bits H2 hash value
+// as its control byte, which has the highest bit unset.
+const (
+	swissGroupSize = 8
+	swissEmpty     = 0x80
+	swissDeleted   = 0xfe
+
+	swissLSB       = 0x0101010101010101
+	swissMSB       = 0x8080808080808080
+	swissEmptyCtrl = swissLSB * swissEmpty
+
+	// swissMaxLoad is the max load factor, which is 7/8.
+	swissMaxLoadNum = 7
+	swissMaxLoadDen = 8
+)
+
+// swissGroup is a group of slots, which are probed together.
+// The 8 control bytes are packed in an uint64, they are matched with
+// SWAR (SIMD within a register) bit tricks.
+type swissGroup[T any] struct {
+	ctrl  uint64
+	slots [swissGroupSize]entry[uint64, T]
+}
+
+// swissMatchH2 returns a bitset of slots whose control byte equals h2,
+// the highest bit of each matched byte is set.
+// It may return false positives, which must be checked by comparing keys,
+// but never for empty or deleted slots.
+func swissMatchH2(ctrl uint64, h2 uint8) uint64 {
+	x := ctrl ^ (swissLSB * uint64(h2))
+	return (x - swissLSB) &^ x & swissMSB
+}
+
+// swissMatchEmpty returns a bitset of empty slots.
+func swissMatchEmpty(ctrl uint64) uint64 {
+	// Empty is 0b10000000, deleted is 0b11111110, shifting bit 1 to
+	// bit 7 tells them apart.
+	return (ctrl &^ (ctrl << 6)) & swissMSB
+}
+
+// swissMatchEmptyOrDeleted returns a bitset of empty or deleted slots.
+func swissMatchEmptyOrDeleted(ctrl uint64) uint64 {
+	return ctrl & swissMSB
+}
+
+// swissMatchFull returns a bitset of full slots.
+func swissMatchFull(ctrl uint64) uint64 {
+	return ^ctrl & swissMSB
+}
+
+// swissFirst returns the index of the first slot in a bitset.
+func swissFirst(bitset uint64) uint64 {
+	return uint64(bits.TrailingZeros64(bitset)) >> 3
+}
+
+func swissSetCtrl(ctrl *uint64, i uint64, c uint8) {
+	shift := i * 8
+	*ctrl = *ctrl&^(0xff<<shift) | uint64(c)<<shift
+}
+
+// swissHash splits the hash value of key into H1, which locates the
+// group to start probing, and H2, which is stored in control bytes.
+//
+// phiMix multiplies by a 32 bits constant, its high bits barely change
+// for keys which differ only in low bits, that makes too many false
+// positive H2 matches. A full 64x64->128 bits multiplication mixes
+// all key bits into both halves.
+func swissHash(key uint64) (h1 uint64, h2 uint8) {
+	h := wyMix(key^wyp0, wyp1)
+	return h, uint8(h >> 57)
+}
+
+// SwissMap is a hash table in the design of Google's SwissTable.
+//
+// Slots are organized in groups of 8, a group's control bytes are
+// checked together to find candidates of a key, most missing keys are
+// rejected by the control bytes without comparing any key.
+// It uses pure Go SWAR bit tricks, it runs on any CPU.
+//
+// Unlike PhiMap, key 0 is a normal key, and the max load factor is 7/8,
+// which uses less memory for the same number of entries.
+// A hit usually touches two cache lines, the control bytes and the slot,
+// PhiMap is faster for hit-heavy workloads, see the benchmarks.
+type SwissMap[T any] struct {
+	groups []swissGroup[T]
+	mask   uint64 // number of groups - 1
+
+	size       int
+	growthLeft int
+}
+
+// NewSwissMap creates a new SwissMap which can hold capacity entries
+// without growing. A negative capacity is treated as zero.
+func NewSwissMap[T any](capacity int) *SwissMap[T] {
+	m := &SwissMap[T]{}
+	m.alloc(swissGroupCount(max(capacity, 0)))
+	return m
+}
+
+// swissGroupCount returns the number of groups to hold capacity entries,
+// it is a power of two and at least 1.
+func swissGroupCount(capacity int) int {
+	slots := (capacity*swissMaxLoadDen + swissMaxLoadNum - 1) / swissMaxLoadNum
+	return max(nextPowerOfTwo((slots+swissGroupSize-1)/swissGroupSize), 1)
+}
+
+func (m *SwissMap[T]) alloc(groupCount int) {
+	m.groups = make([]swissGroup[T], groupCount)
+	for i := range m.groups {
+		m.groups[i].ctrl = swissEmptyCtrl
+	}
+	m.mask = uint64(groupCount - 1)
+	m.size = 0
+	m.growthLeft = groupCount * swissGroupSize * swissMaxLoadNum / swissMaxLoadDen
+}
+
+// Size returns the size of the map.
+func (m *SwissMap[T]) Size() int {
+	return m.size
+}
+
+// Get returns the value if the key is found, else it returns zero value of T.
+func (m *SwissMap[T]) Get(key uint64) T {
+	val, _ := m.Lookup(key)
+	return val
+}
+
+// Lookup returns the value and true if the key is found,
+// else it returns zero value of T and false.
+func (m *SwissMap[T]) Lookup(key uint64) (value T, ok bool) {
+	h1, h2 := swissHash(key)
+	for i := uint64(1); ; i++ {
+		g := &m.groups[h1&m.mask]
+		for match := swissMatchH2(g.ctrl, h2); match != 0; match &= match - 1 {
+			j := swissFirst(match)
+			if g.slots[j].K == key {
+				return g.slots[j].V, true
+			}
+		}
+		if swissMatchEmpty(g.ctrl) != 0 {
+			return
+		}
+		h1 += i // quadratic probing visits every group
+	}
+}
+
+// Has tells whether a key exists in the map.
+func (m *SwissMap[T]) Has(key uint64) bool {
+	_, ok := m.Lookup(key)
+	return ok
+}
+
+// Set adds or updates key with value to the map.
+func (m *SwissMap[T]) Set(key uint64, val T) {
+	h1, h2 := swissHash(key)
+	var slotGroup *swissGroup[T]
+	var slot uint64
+	for i := uint64(1); ; i++ {
+		g := &m.groups[h1&m.mask]
+		for match := swissMatchH2(g.ctrl, h2); match != 0; match &= match - 1 {
+			j := swissFirst(match)
+			if g.slots[j].K == key {
+				g.slots[j].V = val
+				return
+			}
+		}
+		if slotGroup == nil {
+			if match := swissMatchEmptyOrDeleted(g.ctrl); match != 0 {
+				slotGroup, slot = g, swissFirst(match)
+			}
+		}
+		if swissMatchEmpty(g.ctrl) != 0 {
+			break
+		}
+		h1 += i
+	}
+
+	// Reusing a deleted slot doesn't consume growthLeft.
+	if uint8(slotGroup.ctrl>>(slot*8)) == swissEmpty {
+		if m.growthLeft == 0 {
+			m.rehash()
+			m.insertNew(key, val)
+			return
+		}
+		m.growthLeft--
+	}
+	swissSetCtrl(&slotGroup.ctrl, slot, h2)
+	slotGroup.slots[slot].K = key
+	slotGroup.slots[slot].V = val
+	m.size++
+}
+
+// insertNew inserts a key which does not exist in the map,
+// the map must have growthLeft.
+func (m *SwissMap[T]) insertNew(key uint64, val T) {
+	h1, h2 := swissHash(key)
+	for i := uint64(1); ; i++ {
+		g := &m.groups[h1&m.mask]
+		if match := swissMatchEmptyOrDeleted(g.ctrl); match != 0 {
+			j := swissFirst(match)
+			if uint8(g.ctrl>>(j*8)) == swissEmpty {
+				m.growthLeft--
+			}
+			swissSetCtrl(&g.ctrl, j, h2)
+			g.slots[j].K = key
+			g.slots[j].V = val
+			m.size++
+			return
+		}
+		h1 += i
+	}
+}
+
+// rehash doubles the groups, or rehashes to the same number of groups
+// if lots of slots are taken by deleted entries.
+func (m *SwissMap[T]) rehash() {
+	groupCount := len(m.groups)
+	if m.size*32 > groupCount*swissGroupSize*25 {
+		groupCount *= 2
+	}
+	old := m.groups
+	m.alloc(groupCount)
+	for gi := range old {
+		g := &old[gi]
+		for match := swissMatchFull(g.ctrl); match != 0; match &= match - 1 {
+			j := swissFirst(match)
+			m.insertNew(g.slots[j].K, g.slots[j].V)
+		}
+	}
+}
+
+// Delete deletes an element from the map.
+func (m *SwissMap[T]) Delete(key uint64) {
+	var zero T
+	h1, h2 := swissHash(key)
+	for i := uint64(1); ; i++ {
+		g := &m.groups[h1&m.mask]
+		for match := swissMatchH2(g.ctrl, h2); match != 0; match &= match - 1 {
+			j := swissFirst(match)
+			if g.slots[j].K == key {
+				// If the group has an empty slot, no probing sequence
+				// ever passes this group, the slot can be set empty.
+				if swissMatchEmpty(g.ctrl) != 0 {
+					swissSetCtrl(&g.ctrl, j, swissEmpty)
+					m.growthLeft++
+				} else {
+					swissSetCtrl(&g.ctrl, j, swissDeleted)
+				}
+				g.slots[j].K = 0
+				g.slots[j].V = zero
+				m.size--
+				return
+			}
+		}
+		if swissMatchEmpty(g.ctrl) != 0 {
+			return
+		}
+		h1 += i
+	}
+}
+
+// Clear deletes all entries from the map, it keeps the allocated
+// capacity to be reused.
+func (m *SwissMap[T]) Clear() {
+	clear(m.groups)
+	for i := range m.groups {
+		m.groups[i].ctrl = swissEmptyCtrl
+	}
+	m.size = 0
+	m.growthLeft = len(m.groups) * swissGroupSize * swissMaxLoadNum / swissMaxLoadDen
+}
+
+// Copy returns a copy of a SwissMap.
+func (m *SwissMap[T]) Copy() *SwissMap[T] {
+	newMap := &SwissMap[T]{
+		groups:     make([]swissGroup[T], len(m.groups)),
+		mask:       m.mask,
+		size:       m.size,
+		growthLeft: m.growthLeft,
+	}
+	copy(newMap.groups, m.groups)
+	return newMap
+}
+
+// Keys returns all keys in the map, in no particular order.
+func (m *SwissMap[T]) Keys() []uint64 {
+	keys := make([]uint64, 0, m.size)
+	for gi := range m.groups {
+		g := &m.groups[gi]
+		for match := swissMatchFull(g.ctrl); match != 0; match &= match - 1 {
+			keys = append(keys, g.slots[swissFirst(match)].K)
+		}
+	}
+	return keys
+}
+
+// Items returns all key value entries in the map, in no particular order.
+//
+// Values are boxed into Entry.V as interface for compatibility with
+// PhiMap.Items, which allocates for most non-pointer types.
+func (m *SwissMap[T]) Items() []Entry {
+	items := make([]Entry, 0, m.size)
+	for gi := range m.groups {
+		g := &m.groups[gi]
+		for match := swissMatchFull(g.ctrl); match != 0; match &= match - 1 {
+			j := swissFirst(match)
+			items = append(items, Entry{K: g.slots[j].K, V: g.slots[j].V})
+		}
+	}
+	return items
+}
+
+// All returns an iterator over key value pairs in the map,
+// in no particular order.
+//
+// Entries never move unless the map is resized, deleting entries
+// during iteration is safe, deleted entries which have not been reached
+// are not produced. Entries added during iteration may or may not be
+// produced. If the map is resized, the iterator continues with the keys
+// present before resizing.
+func (m *SwissMap[T]) All() iter.Seq2[uint64, T] {
+	return func(yield func(uint64, T) bool) {
+		groups := m.groups
+		for gi := range groups {
+			g := &groups[gi]
+			for match := swissMatchFull(g.ctrl); match != 0; match &= match - 1 {
+				j := swissFirst(match)
+				var k uint64
+				var v T
+				if len(m.groups) > 0 && &m.groups[0] == &groups[0] {
+					// The slot may be deleted during iteration.
+					if swissMatchFull(g.ctrl)&(0x80<<(j*8)) == 0 {
+						continue
+					}
+					k, v = g.slots[j].K, g.slots[j].V
+				} else {
+					var ok bool
+					k = g.slots[j].K
+					if v, ok = m.Lookup(k); !ok {
+						continue
+					}
+				}
+				if !yield(k, v) {
+					return
+				}
+			}
+		}
+	}
+}
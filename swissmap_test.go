@@ -0,0 +1,99 @@
+package phimap
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSwissMatch(t *testing.T) {
+	var ctrl uint64 = swissEmptyCtrl
+	swissSetCtrl(&ctrl, 1, 0x12)
+	swissSetCtrl(&ctrl, 3, swissDeleted)
+	swissSetCtrl(&ctrl, 6, 0x12)
+	swissSetCtrl(&ctrl, 7, 0x7f)
+
+	var got []uint64
+	for match := swissMatchH2(ctrl, 0x12); match != 0; match &= match - 1 {
+		got = append(got, swissFirst(match))
+	}
+	assertEqual(t, 2, len(got))
+	assertEqual(t, uint64(1), got[0])
+	assertEqual(t, uint64(6), got[1])
+
+	assertEqual(t, uint64(0x80), swissMatchEmpty(ctrl)&0xff)
+	assertEqual(t, uint64(0), swissMatchEmpty(ctrl)&(0x80<<24))
+	assertEqual(t, uint64(0x80<<24), swissMatchEmptyOrDeleted(ctrl)&(0x80<<24))
+	assertEqual(t, uint64(0x80<<8|0x80<<48|0x80<<56), swissMatchFull(ctrl))
+}
+
+func TestSwissMap(t *testing.T) {
+	m := NewSwissMap[int](0)
+	ref := make(map[uint64]int)
+	testMapOps(m, ref, rand.New(rand.NewSource(1)), 300000, 20000)
+	assertMapEqual(t, m, ref, 20000)
+
+	keys := m.Keys()
+	assertEqual(t, len(ref), len(keys))
+	for _, k := range keys {
+		_, ok := ref[k]
+		assertEqual(t, true, ok)
+	}
+	items := m.Items()
+	assertEqual(t, len(ref), len(items))
+	for _, e := range items {
+		assertEqual(t, ref[e.K], e.V.(int))
+	}
+
+	m2 := m.Copy()
+	m2.Set(1, 1)
+	assertEqual(t, false, m.Has(1))
+	for k, v := range ref {
+		assertEqual(t, v, m.Get(k))
+		assertEqual(t, v, m2.Get(k))
+	}
+
+	n := 0
+	for k, v := range m.All() {
+		assertEqual(t, ref[k], v)
+		m.Delete(k)
+		n++
+	}
+	assertEqual(t, len(ref), n)
+	assertEqual(t, 0, m.Size())
+
+	m2.Clear()
+	assertEqual(t, 0, m2.Size())
+	assertEqual(t, 0, len(m2.Keys()))
+}
+
+func TestSwissMap_Capacity(t *testing.T) {
+	m := NewSwissMap[int](1000)
+	groups := len(m.groups)
+	for i := 0; i < 1000; i++ {
+		m.Set(uint64(i), i)
+	}
+	assertEqual(t, groups, len(m.groups))
+
+	// Deleted slots are reclaimed without growing.
+	for i := 0; i < 100000; i++ {
+		m.Delete(uint64(i))
+		m.Set(uint64(i+1000), i)
+	}
+	assertEqual(t, 1000, m.Size())
+	assertEqual(t, groups, len(m.groups))
+}
+
+func TestSwissMap_NegativeCapacity(t *testing.T) {
+	for _, capacity := range []int{-1, -8, -1000, 0} {
+		m := NewSwissMap[int](capacity)
+		assertEqual(t, 1, len(m.groups))
+		assertEqual(t, 0, m.Get(1))
+		for i := 0; i < 100; i++ {
+			m.Set(uint64(i), i)
+		}
+		assertEqual(t, 100, m.Size())
+		for i := 0; i < 100; i++ {
+			assertEqual(t, i, m.Get(uint64(i)))
+		}
+	}
+}
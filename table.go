@@ -0,0 +1,534 @@
+package phimap
+
+import "unsafe"
+
+// table is the open addressing hash table with linear probing,
+// which PhiMap, IntMap and SplitMap are defined on.
+//
+// The maps are defined types of table instead of structs wrapping it,
+// thus their inline-able fast paths access the fields directly,
+// a selector through an embedded field counts against the inline
+// budget. The other methods convert the map to table by tab and
+// call the table's.
+type table[K Integer, T any] struct {
+	// debug is zero-sized unless built with tag phimapdebug
+	debug ptrDebug[T]
+
+	// Slots are entries in data, or keys and values in the parallel
+	// arrays skeys and svals if split is set.
+	// dptr and vptr point to the key and value of slot 0, the key and
+	// value of slot i are at dptr + i*kstride and vptr + i*vstride.
+	data    []entry[K, T]
+	skeys   []K
+	svals   []T
+	dptr    unsafe.Pointer
+	vptr    unsafe.Pointer
+	kstride uintptr
+	vstride uintptr
+	split   bool
+
+	fillFactor float64
+	threshold  int
+	size       int
+	mask       uint64
+
+	// shrinkFactor is zero if auto shrinking is disabled
+	shrinkFactor    float64
+	shrinkThreshold int
+
+	// key FREE_KEY is stored in a dedicated side slot
+	hasZero bool
+	zeroVal T
+
+	// hashFn is nil for all maps but HashMap, the others use phiMix
+	// to hash keys.
+	// The check is written inline everywhere, since a function call
+	// is too expensive for the inliner.
+	hashFn func(key uint64) uint64
+
+	// robinHood is set for a RobinHoodMap, entries are inserted with
+	// Robin Hood hashing when resizing.
+	robinHood bool
+}
+
+// entry is the key value pair stored in a hash table.
+// Value is stored as T to avoid the cost of boxing and type assertion.
+type entry[K Integer, T any] struct {
+	K K
+	V T
+}
+
+// init allocates the hash table with options o.
+func (t *table[K, T]) init(o *options) {
+	t.fillFactor = o.fillFactor
+	t.shrinkFactor = o.shrinkFactor
+	t.alloc(arraySize(o.capacity, o.fillFactor))
+}
+
+// capacity returns the number of slots of the hash table.
+func (t *table[K, T]) capacity() int {
+	return int(t.mask) + 1
+}
+
+// getK helps to eliminate slice bounds checking
+func (t *table[K, T]) getK(ptr uint64) *K {
+	return (*K)(unsafe.Pointer(uintptr(t.dptr) + uintptr(ptr)*t.kstride))
+}
+
+// getV helps to eliminate slice bounds checking
+func (t *table[K, T]) getV(ptr uint64) *T {
+	return (*T)(unsafe.Pointer(uintptr(t.vptr) + uintptr(ptr)*t.vstride))
+}
+
+// lookup is same as the maps' Lookup, but it respects t.hashFn.
+func (t *table[K, T]) lookup(key K) (value T, ok bool) {
+	if key == FREE_KEY {
+		return t.zeroVal, t.hasZero
+	}
+	ptr, found := t.findSlot(key)
+	if found {
+		return *t.getV(ptr), true
+	}
+	return
+}
+
+// findSlot returns the slot of key and true if key is found, else it
+// returns the free slot where key should be inserted and false.
+// key must not be FREE_KEY.
+func (t *table[K, T]) findSlot(key K) (ptr uint64, found bool) {
+	ptr = phiMix(uint64(key))
+	if t.hashFn != nil {
+		ptr = t.hashFn(uint64(key))
+	}
+	for {
+		ptr &= t.mask
+		k := *t.getK(ptr)
+		if k == key {
+			return ptr, true
+		}
+		if k == FREE_KEY {
+			return ptr, false
+		}
+		ptr += 1
+	}
+}
+
+// insertAt inserts key with val to the free slot ptr returned by findSlot,
+// the table must not be changed after calling findSlot.
+func (t *table[K, T]) insertAt(ptr uint64, key K, val T) {
+	*t.getK(ptr) = key
+	*t.getV(ptr) = val
+	if t.size >= t.threshold {
+		t.rehash()
+	} else {
+		t.size++
+	}
+}
+
+// deleteAt deletes the entry at slot ptr returned by findSlot.
+func (t *table[K, T]) deleteAt(ptr uint64) {
+	t.shiftKeys(ptr)
+	t.size--
+	if t.size < t.shrinkThreshold {
+		t.shrink()
+	}
+}
+
+// setZero sets or deletes the value of key FREE_KEY.
+func (t *table[K, T]) setZero(val T, ok bool) {
+	if !ok {
+		t.delete(FREE_KEY)
+		return
+	}
+	if !t.hasZero {
+		t.hasZero = true
+		t.size++
+	}
+	t.zeroVal = val
+}
+
+// set adds or updates key with value to the table.
+func (t *table[K, T]) set(key K, val T) {
+	if key == FREE_KEY {
+		t.setZero(val, true)
+		return
+	}
+	ptr := phiMix(uint64(key))
+	if t.hashFn != nil {
+		ptr = t.hashFn(uint64(key))
+	}
+	for {
+		ptr &= t.mask
+		k := *t.getK(ptr)
+		if k == FREE_KEY {
+			*t.getK(ptr) = key
+			*t.getV(ptr) = val
+			if t.size >= t.threshold {
+				t.rehash()
+			} else {
+				t.size++
+			}
+			return
+		}
+		if k == key {
+			*t.getV(ptr) = val
+			return
+		}
+		ptr += 1
+	}
+}
+
+// delete deletes key from the table.
+func (t *table[K, T]) delete(key K) {
+	if key == FREE_KEY {
+		if t.hasZero {
+			var zero T
+			t.hasZero = false
+			t.zeroVal = zero
+			t.size--
+			if t.size < t.shrinkThreshold {
+				t.shrink()
+			}
+		}
+		return
+	}
+	if ptr, found := t.findSlot(key); found {
+		t.deleteAt(ptr)
+	}
+}
+
+// reserve grows the table if needed, to make sure that another n entries
+// can be added to the table without growing again.
+func (t *table[K, T]) reserve(n int) {
+	need := t.size + n
+	if need <= t.threshold {
+		return
+	}
+	newCapacity := arraySize(need, t.fillFactor)
+	if newCapacity > t.capacity() {
+		t.resize(newCapacity)
+	}
+}
+
+func (t *table[K, T]) rehash() {
+	t.resize(t.capacity() * 2)
+}
+
+// alloc allocates a new empty hash table of newCapacity,
+// newCapacity must be a power of two.
+func (t *table[K, T]) alloc(newCapacity int) {
+	t.threshold = calcThreshold(newCapacity, t.fillFactor)
+	t.shrinkThreshold = calcShrinkThreshold(newCapacity, t.fillFactor, t.shrinkFactor)
+	t.mask = uint64(newCapacity - 1)
+	if t.split {
+		t.skeys = make([]K, newCapacity)
+		t.svals = make([]T, newCapacity)
+		t.dptr = unsafe.Pointer(&t.skeys[0])
+		t.vptr = unsafe.Pointer(&t.svals[0])
+		t.kstride = unsafe.Sizeof(t.skeys[0])
+		t.vstride = unsafe.Sizeof(t.svals[0])
+	} else {
+		t.data = make([]entry[K, T], newCapacity)
+		t.dptr = unsafe.Pointer(&t.data[0].K)
+		t.vptr = unsafe.Pointer(&t.data[0].V)
+		t.kstride = unsafe.Sizeof(t.data[0])
+		t.vstride = unsafe.Sizeof(t.data[0])
+	}
+}
+
+// clear deletes all entries, it keeps the allocated capacity.
+// Keys and values are zeroed, thus the values can be garbage collected.
+func (t *table[K, T]) clear() {
+	var zero T
+	t.debug.invalidate()
+	clear(t.data)
+	clear(t.skeys)
+	clear(t.svals)
+	t.hasZero = false
+	t.zeroVal = zero
+	t.size = 0
+}
+
+// reset deletes all entries, and reallocates the table to be able to
+// hold capacity entries without growing.
+func (t *table[K, T]) reset(capacity int) {
+	var zero T
+	t.debug.rehash()
+	t.alloc(arraySize(capacity, t.fillFactor))
+	t.hasZero = false
+	t.zeroVal = zero
+	t.size = 0
+}
+
+// replace replaces the hash table and all entries with tmp's,
+// the debug state and the hashing are kept.
+func (t *table[K, T]) replace(tmp *table[K, T]) {
+	t.debug.rehash()
+	debug, hashFn, robinHood := t.debug, t.hashFn, t.robinHood
+	*t = *tmp
+	t.debug, t.hashFn, t.robinHood = debug, hashFn, robinHood
+}
+
+// compact shrinks the table to the smallest capacity which can hold
+// the entries.
+func (t *table[K, T]) compact() {
+	newCapacity := arraySize(t.size, t.fillFactor)
+	if newCapacity < t.capacity() {
+		t.resize(newCapacity)
+	}
+}
+
+func (t *table[K, T]) shrink() {
+	newCapacity := max(arraySize(t.size, t.fillFactor), arraySize(initSize, t.fillFactor))
+	if newCapacity < t.capacity() {
+		t.resize(newCapacity)
+	}
+}
+
+// resize rehashes all entries into a new hash table of newCapacity,
+// newCapacity must be a power of two.
+func (t *table[K, T]) resize(newCapacity int) {
+	t.debug.rehash()
+	old := *t
+	t.alloc(newCapacity)
+	t.size = 0
+	if t.hasZero {
+		t.size = 1
+	}
+
+COPY:
+	for i := uint64(0); i <= old.mask; i++ {
+		k := *old.getK(i)
+		if k == FREE_KEY {
+			continue
+		}
+		t.size++
+		if t.robinHood {
+			t.rhInsert(k, *old.getV(i))
+			continue
+		}
+
+		// Manually inline insert to avoid a function call per entry.
+		ptr := phiMix(uint64(k))
+		if t.hashFn != nil {
+			ptr = t.hashFn(uint64(k))
+		}
+		for {
+			ptr &= t.mask
+			if *t.getK(ptr) == FREE_KEY {
+				*t.getK(ptr) = k
+				*t.getV(ptr) = *old.getV(i)
+				continue COPY
+			}
+			ptr += 1
+		}
+	}
+}
+
+// insert inserts a key which does not exist in the table, key must not
+// be FREE_KEY, and the hash table must have a free slot.
+// It does not change t.size.
+func (t *table[K, T]) insert(key K, val T) {
+	if t.robinHood {
+		t.rhInsert(key, val)
+		return
+	}
+	ptr := phiMix(uint64(key))
+	if t.hashFn != nil {
+		ptr = t.hashFn(uint64(key))
+	}
+	for {
+		ptr &= t.mask
+		if *t.getK(ptr) == FREE_KEY {
+			*t.getK(ptr) = key
+			*t.getV(ptr) = val
+			return
+		}
+		ptr += 1
+	}
+}
+
+// shiftKeys deletes the entry at slot pos, it shifts the following
+// entries in the probing cluster backward to fill the hole.
+func (t *table[K, T]) shiftKeys(pos uint64) uint64 {
+	var zero T
+	var last, slot uint64
+	t.debug.invalidate()
+	for {
+		last = pos
+		pos = last + 1
+		for {
+			pos &= t.mask
+			k := *t.getK(pos)
+			if k == FREE_KEY {
+				*t.getK(last) = FREE_KEY
+				*t.getV(last) = zero
+				return last
+			}
+
+			slot = phiMix(uint64(k))
+			if t.hashFn != nil {
+				slot = t.hashFn(uint64(k))
+			}
+			slot &= t.mask
+			if last <= pos {
+				if last >= slot || slot > pos {
+					break
+				}
+			} else {
+				if last >= slot && slot > pos {
+					break
+				}
+			}
+			pos += 1
+		}
+		*t.getK(last) = *t.getK(pos)
+		*t.getV(last) = *t.getV(pos)
+	}
+}
+
+// deleteFunc deletes all entries for which f returns true,
+// it returns the number of entries deleted.
+// See PhiMap.DeleteFunc for details.
+func (t *table[K, T]) deleteFunc(f func(k K, v T) bool) (deleted int) {
+	var zero T
+	if t.hasZero && f(FREE_KEY, t.zeroVal) {
+		t.hasZero = false
+		t.zeroVal = zero
+		deleted++
+	}
+
+	// Start right after a free slot, thus no probing cluster wraps
+	// around the start position, entries before the current one in
+	// its cluster are already in their final slots.
+	mask := t.mask
+	start := uint64(0)
+	for start < mask && *t.getK(start) != FREE_KEY {
+		start++
+	}
+	hole := false // whether there is a free slot in the current cluster
+	for i := uint64(1); i <= mask+1; i++ {
+		pos := (start + i) & mask
+		k := *t.getK(pos)
+		if k == FREE_KEY {
+			hole = false
+			continue
+		}
+		if f(k, *t.getV(pos)) {
+			*t.getK(pos) = FREE_KEY
+			*t.getV(pos) = zero
+			deleted++
+			hole = true
+			continue
+		}
+		if !hole {
+			continue
+		}
+		ptr := phiMix(uint64(k))
+		if t.hashFn != nil {
+			ptr = t.hashFn(uint64(k))
+		}
+		for {
+			ptr &= mask
+			if ptr == pos {
+				break
+			}
+			if *t.getK(ptr) == FREE_KEY {
+				*t.getK(ptr) = k
+				*t.getV(ptr) = *t.getV(pos)
+				*t.getK(pos) = FREE_KEY
+				*t.getV(pos) = zero
+				break
+			}
+			ptr += 1
+		}
+	}
+
+	if deleted > 0 {
+		t.debug.invalidate()
+	}
+	t.size -= deleted
+	if t.size < t.shrinkThreshold {
+		t.shrink()
+	}
+	return deleted
+}
+
+// clone returns a copy of the table, if the table's size reaches the
+// threshold, the new table's capacity will be twice of the old.
+func (t *table[K, T]) clone() *table[K, T] {
+	capacity := t.capacity()
+	if t.size >= t.threshold {
+		capacity *= 2
+	}
+	return t.copy(capacity)
+}
+
+// cloneWithCapacity returns a copy of the table, which can hold another
+// n entries without growing.
+func (t *table[K, T]) cloneWithCapacity(n int) *table[K, T] {
+	capacity := t.capacity()
+	if need := t.size + n; need > t.threshold {
+		capacity = max(capacity, arraySize(need, t.fillFactor))
+	}
+	return t.copy(capacity)
+}
+
+// copy returns a copy of the table with a hash table of capacity,
+// capacity must be a power of two and large enough to hold the entries.
+func (t *table[K, T]) copy(capacity int) *table[K, T] {
+	newTable := &table[K, T]{
+		fillFactor:   t.fillFactor,
+		shrinkFactor: t.shrinkFactor,
+		hasZero:      t.hasZero,
+		zeroVal:      t.zeroVal,
+		size:         t.size,
+		hashFn:       t.hashFn,
+		robinHood:    t.robinHood,
+		split:        t.split,
+	}
+	newTable.alloc(capacity)
+
+	// Entries are at the same positions in a table of same capacity.
+	if capacity == t.capacity() {
+		copy(newTable.data, t.data)
+		copy(newTable.skeys, t.skeys)
+		copy(newTable.svals, t.svals)
+		return newTable
+	}
+	for i := uint64(0); i <= t.mask; i++ {
+		if k := *t.getK(i); k != FREE_KEY {
+			newTable.insert(k, *t.getV(i))
+		}
+	}
+	return newTable
+}
+
+// keys returns all keys in the table, in no particular order.
+func (t *table[K, T]) keys() []K {
+	keys := make([]K, 0, t.size)
+	if t.hasZero {
+		keys = append(keys, FREE_KEY)
+	}
+	for i := uint64(0); i <= t.mask; i++ {
+		if k := *t.getK(i); k != FREE_KEY {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// items returns all key value entries in the table, in no particular
+// order, values are boxed into Entry.V.
+func (t *table[K, T]) items() []Entry {
+	items := make([]Entry, 0, t.size)
+	if t.hasZero {
+		items = append(items, Entry{K: FREE_KEY, V: t.zeroVal})
+	}
+	for i := uint64(0); i <= t.mask; i++ {
+		if k := *t.getK(i); k != FREE_KEY {
+			items = append(items, Entry{K: uint64(k), V: *t.getV(i)})
+		}
+	}
+	return items
+}
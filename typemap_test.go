@@ -126,6 +126,50 @@ func TestTypeMap_Error(t *testing.T) {
 	}
 }
 
+func TestTypeMap_Lookup(t *testing.T) {
+	m := NewTypeMap[int]()
+	builder := func(x int) func() (int, error) {
+		return func() (int, error) { return x, nil }
+	}
+
+	// cache zero values, which can't be distinguished by GetByType
+	for _, val := range testTypeMapValues1[:3] {
+		_, err := m.SetByType(reflect.TypeOf(val), builder(0))
+		if err != nil {
+			t.Errorf("got unexpected error: %v", err)
+		}
+	}
+	for _, val := range testTypeMapValues1[3:] {
+		typeptr := (*iface)(unsafe.Pointer(&val)).tab
+		_, err := m.SetByUintptr(typeptr, builder(0))
+		if err != nil {
+			t.Errorf("got unexpected error: %v", err)
+		}
+	}
+
+	m.calibrate(true)
+
+	for _, val := range testTypeMapValues1[:3] {
+		got, ok := m.LookupByType(reflect.TypeOf(val))
+		if !ok || got != 0 {
+			t.Errorf("expected (0, true), got (%v, %v)", got, ok)
+		}
+	}
+	for _, val := range testTypeMapValues1[3:] {
+		typeptr := (*iface)(unsafe.Pointer(&val)).tab
+		got, ok := m.LookupByUintptr(typeptr)
+		if !ok || got != 0 {
+			t.Errorf("expected (0, true), got (%v, %v)", got, ok)
+		}
+	}
+	for _, val := range testTypeMapValues2 {
+		got, ok := m.LookupByType(reflect.TypeOf(val))
+		if ok || got != 0 {
+			t.Errorf("expected (0, false), got (%v, %v)", got, ok)
+		}
+	}
+}
+
 type TestType1 struct{ A int }
 type TestType2 struct{ B int32 }
 type TestType3 struct{ C int64 }